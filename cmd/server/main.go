@@ -3,18 +3,25 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/sksmith/go-base-ms/internal/api"
 	"github.com/sksmith/go-base-ms/internal/config"
 	"github.com/sksmith/go-base-ms/internal/db"
 	"github.com/sksmith/go-base-ms/internal/health"
+	"github.com/sksmith/go-base-ms/internal/id"
 	"github.com/sksmith/go-base-ms/internal/kafka"
+	"github.com/sksmith/go-base-ms/internal/lifecycle"
 	"github.com/sksmith/go-base-ms/internal/logger"
+	"github.com/sksmith/go-base-ms/internal/stats"
 	"github.com/sksmith/go-base-ms/internal/version"
 )
 
@@ -26,6 +33,22 @@ var (
 	BuiltBy = "unknown"
 )
 
+// deps are the constructors run uses to reach configuration and external
+// systems, replaced with fakes in tests.
+type deps struct {
+	loadConfig   func() (*config.Config, error)
+	connectDB    func(context.Context, config.DatabaseConfig, ...db.Option) (*db.DB, error)
+	connectKafka func(config.KafkaConfig, config.SchemaRegistryConfig, *slog.Logger, ...kafka.Option) (*kafka.Client, error)
+	listen       func(network, address string) (net.Listener, error)
+}
+
+var defaultDeps = deps{
+	loadConfig:   loadConfig,
+	connectDB:    db.New,
+	connectKafka: kafka.New,
+	listen:       net.Listen,
+}
+
 func main() {
 	// Set version info that will be injected by GoReleaser
 	version.Version = Version
@@ -34,6 +57,8 @@ func main() {
 	version.BuiltBy = BuiltBy
 
 	log := logger.New()
+	// logger.WithContext and logger.Named build on the default logger
+	slog.SetDefault(log)
 
 	versionInfo := version.Get()
 	log.Info("go-base-ms starting",
@@ -42,53 +67,191 @@ func main() {
 		"built_at", versionInfo.Date,
 		"built_by", versionInfo.BuiltBy)
 
-	cfg, err := config.Load()
-	if err != nil {
-		log.Error("failed to load config", "error", err)
+	if err := run(context.Background(), log, defaultDeps); err != nil {
 		os.Exit(1)
 	}
+}
+
+// run starts the service, timing each startup phase, and serves until a
+// shutdown signal arrives or ctx is cancelled. It returns an error only
+// when startup fails.
+func run(ctx context.Context, log *slog.Logger, d deps) error {
+	boot := newStartup(log)
+
+	var cfg *config.Config
+	err := boot.phase("config", func() error {
+		var err error
+		cfg, err = d.loadConfig()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range config.UnknownVars() {
+		log.Warn("unrecognized environment variable", "name", name)
+	}
 
 	log.Info("starting server", "port", cfg.Port)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	database, err := db.New(ctx, cfg.Database)
+	var database *db.DB
+	err = boot.phase("database", func() error {
+		var err error
+		database, err = d.connectDB(ctx, cfg.Database, db.WithLogger(log))
+		return err
+	})
 	if err != nil {
-		log.Error("failed to connect to database", "error", err)
-		os.Exit(1)
+		return err
 	}
 	defer database.Close()
 
-	kafkaClient, err := kafka.New(cfg.Kafka, cfg.SchemaRegistry, log)
+	ids, err := id.NewGenerator(cfg.IDFormat)
 	if err != nil {
-		log.Error("failed to connect to kafka", "error", err)
-		os.Exit(1)
+		log.Error("failed to create id generator", "error", err)
+		return err
+	}
+
+	var metricsRegistry *prometheus.Registry
+	if cfg.HTTP.Metrics {
+		metricsRegistry = prometheus.NewRegistry()
+		metricsRegistry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+
+	// Migrations run once the server is listening, so liveness passes
+	// while they run and readiness holds traffic back until they finish
+	var migrations []migration
+
+	kafkaOpts := []kafka.Option{kafka.WithIDGenerator(ids), kafka.WithStatsRegistry(metricsRegistry)}
+	if cfg.Kafka.OffsetStore == "db" {
+		offsets := kafka.NewDBOffsetStore(database, cfg.Kafka.GroupID)
+		migrations = append(migrations, offsets.EnsureTable)
+		kafkaOpts = append(kafkaOpts, kafka.WithOffsetStore(offsets))
+	}
+
+	var kafkaClient *kafka.Client
+	err = boot.phase("kafka", func() error {
+		var err error
+		kafkaClient, err = d.connectKafka(cfg.Kafka, cfg.SchemaRegistry, logger.Named("kafka"), kafkaOpts...)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 	defer kafkaClient.Close()
 
+	if cfg.Kafka.SelfTest {
+		err := boot.phase("kafka_selftest", func() error {
+			return kafkaClient.SelfTest(ctx, cfg.Kafka.SelfTestTopic, cfg.Kafka.SelfTestTimeout)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	workers := lifecycle.New(log)
+
+	if cfg.StatsLogInterval > 0 {
+		workers.Go("stats-logger", func() {
+			stats.Run(ctx, cfg.StatsLogInterval, log, database)
+		})
+	}
+
 	healthChecker := health.New(database, kafkaClient)
+	healthChecker.SetTimeout(cfg.Timeouts.HealthCheck)
+	healthChecker.SetRequireChecks(cfg.Health.RequireChecks)
+	healthChecker.SetGroup(health.GroupRead, cfg.Health.ReadChecks...)
+	healthChecker.SetGroup(health.GroupWrite, cfg.Health.WriteChecks...)
+	if len(migrations) > 0 {
+		healthChecker.MarkMigrating()
+	}
+	// Database and Kafka connected above, so startup is complete
+	healthChecker.MarkStarted()
+
+	snapshotSignals := make(chan os.Signal, 1)
+	notifySnapshot(snapshotSignals)
+	workers.Go("status-snapshot", func() {
+		stats.OnSignal(ctx, snapshotSignals, func() {
+			stats.Snapshot(log, database, kafkaClient, healthChecker)
+		})
+	})
 
-	router := api.NewRouter(log, healthChecker)
+	router := api.NewRouter(log, healthChecker,
+		api.WithTrailingSlash(api.TrailingSlashMode(cfg.HTTP.TrailingSlash)),
+		api.WithPropagateHeaders(cfg.HTTP.PropagateHeaders...),
+		api.WithPanicThreshold(cfg.Health.PanicThreshold, cfg.Health.PanicWindow),
+		api.WithServerTiming(cfg.HTTP.ServerTiming),
+		api.WithIDGenerator(ids),
+		api.WithLoadShedding(cfg.HTTP.LoadShedThreshold, cfg.HTTP.LoadShedPercent),
+		api.WithResponseCache(cfg.HTTP.CacheTTL, cfg.HTTP.CachePaths...),
+		api.WithRateLimit(cfg.RateLimit.KeyHeader,
+			api.RateLimit{Rate: cfg.RateLimit.RPS, Burst: cfg.RateLimit.Burst},
+			rateLimitOverrides(cfg.RateLimit.Overrides)),
+		api.WithMetrics(metricsRegistry),
+		api.WithMaxBodyBytes(cfg.HTTP.MaxRequestBodyBytes),
+		api.WithURLLimits(cfg.HTTP.MaxURLLength, cfg.HTTP.MaxQueryLength),
+		api.WithSwaggerUI(cfg.HTTP.SwaggerUI),
+		api.WithConfig(cfg),
+		api.WithAdminToken(cfg.HTTP.AdminToken),
+		api.WithCORS(cfg.CORS.AllowedOrigins, cfg.CORS.AllowedMethods, cfg.CORS.AllowedHeaders),
+	)
+
+	var handler http.Handler = router
+	if cfg.Timeouts.Request > 0 {
+		handler = http.TimeoutHandler(router, cfg.Timeouts.Request,
+			fmt.Sprintf(`{"error":"request timeout","code":%q}`, api.CodeTimeout))
+	}
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Handler:      handler,
+		ReadTimeout:  cfg.Timeouts.ServerRead,
+		WriteTimeout: cfg.Timeouts.ServerWrite,
+		IdleTimeout:  cfg.Timeouts.ServerIdle,
+	}
+
+	if cfg.TLS.Enabled() {
+		srv.TLSConfig = cfg.TLS.ServerConfig()
+	}
+
+	var listener net.Listener
+	err = boot.phase("server_listen", func() error {
+		var err error
+		listener, err = d.listen("tcp", srv.Addr)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
 	go func() {
-		log.Info("server starting", "addr", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("server starting", "addr", listener.Addr().String(), "tls", cfg.TLS.Enabled())
+		var err error
+		if cfg.TLS.Enabled() {
+			err = srv.ServeTLS(listener, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = srv.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Error("server failed", "error", err)
 			cancel()
 		}
 	}()
 
+	if err := runMigrations(ctx, boot, healthChecker, migrations); err != nil {
+		srv.Close()
+		return err
+	}
+	boot.done()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 
 	select {
 	case <-sigChan:
@@ -97,12 +260,49 @@ func main() {
 		log.Info("context cancelled")
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	router.BeginShutdown()
+	if delay := cfg.Timeouts.ShutdownDrainDelay; delay > 0 {
+		// Keep serving while load balancers notice readiness failing; a
+		// second signal skips the wait
+		log.Info("waiting before draining server", "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-sigChan:
+		}
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
 	defer shutdownCancel()
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
+	if err := lifecycle.ShutdownServer(shutdownCtx, srv, cfg.Timeouts.Shutdown, router.InFlight, log); err != nil {
 		log.Error("server shutdown failed", "error", err)
 	}
 
+	// Stop background workers and wait for them before clients are closed
+	cancel()
+	if err := workers.Wait(shutdownCtx); err != nil {
+		log.Error("background workers shutdown failed", "error", err)
+	}
+
 	log.Info("server stopped")
+	return nil
+}
+
+// loadConfig loads the configuration, resolving vault:// references when
+// VAULT_ADDR is set.
+func loadConfig() (*config.Config, error) {
+	var opts []config.LoadOption
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		opts = append(opts, config.WithSecretsProvider("vault", config.NewVaultProvider(addr, os.Getenv("VAULT_TOKEN"))))
+	}
+	return config.Load(opts...)
+}
+
+// rateLimitOverrides converts configured per-key limits to router limits.
+func rateLimitOverrides(overrides map[string]config.RateLimitOverride) map[string]api.RateLimit {
+	limits := make(map[string]api.RateLimit, len(overrides))
+	for key, o := range overrides {
+		limits[key] = api.RateLimit{Rate: o.RPS, Burst: o.Burst}
+	}
+	return limits
 }
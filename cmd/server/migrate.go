@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+// migration prepares database schema the service depends on.
+type migration func(ctx context.Context) error
+
+// runMigrations applies migrations in order as the migrations startup
+// phase. The caller marks h as migrating before the server starts
+// listening; readiness reports healthy again only once every migration
+// succeeded.
+func runMigrations(ctx context.Context, boot *startup, h *health.Health, migrations []migration) error {
+	err := boot.phase("migrations", func() error {
+		for _, m := range migrations {
+			if err := m(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	h.MarkMigrated()
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/api"
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+type okChecker struct{}
+
+func (okChecker) Ping(context.Context) error { return nil }
+
+// probe requests path from router and returns the status code and the
+// reported health status.
+func probe(t *testing.T, router http.Handler, path string) (int, health.Status) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var check health.Check
+	if err := json.NewDecoder(w.Body).Decode(&check); err != nil {
+		t.Fatalf("failed to decode %s response: %v", path, err)
+	}
+	return w.Code, check.Status
+}
+
+func TestRunMigrations_ReadinessDuringMigration(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := health.New(okChecker{}, okChecker{})
+	router := api.NewRouter(log, h)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+
+	h.MarkMigrating()
+	done := make(chan error, 1)
+	go func() {
+		done <- runMigrations(context.Background(), newStartup(log), h, []migration{slow})
+	}()
+	<-started
+
+	if code, status := probe(t, router, "/health/ready"); code != http.StatusServiceUnavailable || status != health.StatusMigrating {
+		t.Errorf("readiness during migration = %d %s, want %d %s", code, status, http.StatusServiceUnavailable, health.StatusMigrating)
+	}
+	if code, status := probe(t, router, "/health/live"); code != http.StatusOK || status != health.StatusHealthy {
+		t.Errorf("liveness during migration = %d %s, want %d %s", code, status, http.StatusOK, health.StatusHealthy)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("runMigrations() error = %v", err)
+	}
+
+	if code, status := probe(t, router, "/health/ready"); code != http.StatusOK || status != health.StatusHealthy {
+		t.Errorf("readiness after migration = %d %s, want %d %s", code, status, http.StatusOK, health.StatusHealthy)
+	}
+}
+
+func TestRunMigrations_Failure(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(slog.NewJSONHandler(buf, nil))
+	h := health.New(okChecker{}, okChecker{})
+
+	h.MarkMigrating()
+	failing := func(context.Context) error { return errors.New("permission denied") }
+	second := func(context.Context) error {
+		t.Error("migration ran after an earlier one failed")
+		return nil
+	}
+
+	err := runMigrations(context.Background(), newStartup(log), h, []migration{failing, second})
+	if err == nil {
+		t.Fatal("runMigrations() error = nil, want failure")
+	}
+	if status := h.Readiness(context.Background()).Status; status != health.StatusMigrating {
+		t.Errorf("readiness after failed migration = %s, want %s", status, health.StatusMigrating)
+	}
+}
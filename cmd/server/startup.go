@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// startup times the phases of service startup so slow cold starts show
+// which dependency dominates.
+type startup struct {
+	logger *slog.Logger
+	now    func() time.Time
+	begin  time.Time
+}
+
+func newStartup(logger *slog.Logger) *startup {
+	return &startup{logger: logger, now: time.Now, begin: time.Now()}
+}
+
+// phase runs fn and logs its duration, or the failure when fn returns an
+// error.
+func (s *startup) phase(name string, fn func() error) error {
+	start := s.now()
+	err := fn()
+	elapsed := s.now().Sub(start)
+
+	if err != nil {
+		s.logger.Error("startup phase failed", "phase", name, "duration_ms", elapsed.Milliseconds(), "error", err)
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	s.logger.Info("startup phase complete", "phase", name, "duration_ms", elapsed.Milliseconds())
+	return nil
+}
+
+// done logs the total time since startup began.
+func (s *startup) done() {
+	s.logger.Info("startup complete", "duration_ms", s.now().Sub(s.begin).Milliseconds())
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/db"
+	"github.com/sksmith/go-base-ms/internal/kafka"
+)
+
+// logRecords decodes the JSON log lines written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestStartup_Phase(t *testing.T) {
+	buf := &bytes.Buffer{}
+	boot := newStartup(slog.New(slog.NewJSONHandler(buf, nil)))
+
+	clock := time.Unix(0, 0)
+	boot.begin = clock
+	boot.now = func() time.Time { return clock }
+
+	_ = boot.phase("database", func() error {
+		clock = clock.Add(250 * time.Millisecond)
+		return nil
+	})
+	err := boot.phase("kafka", func() error {
+		clock = clock.Add(100 * time.Millisecond)
+		return errors.New("brokers unreachable")
+	})
+	boot.done()
+
+	if err == nil || !strings.HasPrefix(err.Error(), "kafka: ") {
+		t.Errorf("phase() error = %v, want error prefixed with phase name", err)
+	}
+
+	records := logRecords(t, buf)
+	want := []struct {
+		msg      string
+		phase    string
+		duration float64
+	}{
+		{"startup phase complete", "database", 250},
+		{"startup phase failed", "kafka", 100},
+		{"startup complete", "", 350},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d log records, want %d: %v", len(records), len(want), records)
+	}
+	for i, w := range want {
+		r := records[i]
+		if r["msg"] != w.msg {
+			t.Errorf("record %d msg = %v, want %v", i, r["msg"], w.msg)
+		}
+		if w.phase != "" && r["phase"] != w.phase {
+			t.Errorf("record %d phase = %v, want %v", i, r["phase"], w.phase)
+		}
+		if r["duration_ms"] != w.duration {
+			t.Errorf("record %d duration_ms = %v, want %v", i, r["duration_ms"], w.duration)
+		}
+	}
+}
+
+func TestRun_StopsAtFailedPhase(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(slog.NewJSONHandler(buf, nil))
+
+	kafkaCalled := false
+	fakes := deps{
+		loadConfig: func() (*config.Config, error) {
+			return &config.Config{Port: 8080}, nil
+		},
+		connectDB: func(context.Context, config.DatabaseConfig, ...db.Option) (*db.DB, error) {
+			time.Sleep(20 * time.Millisecond)
+			return nil, errors.New("connection refused")
+		},
+		connectKafka: func(config.KafkaConfig, config.SchemaRegistryConfig, *slog.Logger, ...kafka.Option) (*kafka.Client, error) {
+			kafkaCalled = true
+			return nil, errors.New("unexpected")
+		},
+		listen: func(string, string) (net.Listener, error) {
+			t.Error("listen called after a failed phase")
+			return nil, errors.New("unexpected")
+		},
+	}
+
+	err := run(context.Background(), log, fakes)
+	if err == nil || !strings.Contains(err.Error(), "database: connection refused") {
+		t.Fatalf("run() error = %v, want database phase error", err)
+	}
+	if kafkaCalled {
+		t.Error("kafka connected after database phase failed")
+	}
+
+	phases := make(map[string]map[string]interface{})
+	for _, r := range logRecords(t, buf) {
+		if phase, ok := r["phase"].(string); ok {
+			phases[phase] = r
+		}
+	}
+
+	if r := phases["config"]; r == nil || r["msg"] != "startup phase complete" {
+		t.Errorf("config phase record = %v, want completed", r)
+	}
+	r := phases["database"]
+	if r == nil || r["msg"] != "startup phase failed" {
+		t.Fatalf("database phase record = %v, want failed", r)
+	}
+	if ms, _ := r["duration_ms"].(float64); ms < 20 {
+		t.Errorf("database duration_ms = %v, want at least 20", r["duration_ms"])
+	}
+}
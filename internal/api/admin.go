@@ -0,0 +1,31 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// WithAdminToken sets the bearer token protected admin endpoints require.
+// With no token those endpoints refuse every request, so they are never
+// exposed by accident.
+func WithAdminToken(token string) Option {
+	return func(r *Router) {
+		r.adminToken = token
+	}
+}
+
+// requireAdmin answers 401 unless the request carries the admin token as
+// "Authorization: Bearer <token>".
+func (r *Router) requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || r.adminToken == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(r.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			r.respondError(w, http.StatusUnauthorized, CodeUnauthorized, "Admin token required")
+			return
+		}
+		h(w, req)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestRouter_RequireAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", token: "admin-secret", authorization: "Bearer admin-secret", wantStatus: http.StatusOK},
+		{name: "missing header", token: "admin-secret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "admin-secret", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "admin-secret", authorization: "Basic admin-secret", wantStatus: http.StatusUnauthorized},
+		{name: "no token configured", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithConfig(cfg), WithAdminToken(tt.token))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/config.env", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusUnauthorized {
+				return
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want Bearer", got)
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if resp.Code != CodeUnauthorized {
+				t.Errorf("code = %q, want %q", resp.Code, CodeUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRouter_AdminRoutesRequireToken(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithConfig(cfg), WithAdminToken("admin-secret"))
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: "/api/v1/admin/logging"},
+		{method: http.MethodPut, path: "/api/v1/admin/logging", body: `{"sample_rate": 1}`},
+		{method: http.MethodGet, path: "/api/v1/admin/config.env"},
+	}
+
+	for _, rt := range routes {
+		t.Run(rt.method+" "+rt.path, func(t *testing.T) {
+			req := httptest.NewRequest(rt.method, rt.path, strings.NewReader(rt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("without token status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+
+			req = httptest.NewRequest(rt.method, rt.path, strings.NewReader(rt.body))
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("with token status = %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedResponse is a complete 200 response replayed for later requests.
+type cachedResponse struct {
+	header  http.Header
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// responseCache stores GET responses for a fixed set of paths for ttl.
+type responseCache struct {
+	ttl   time.Duration
+	paths map[string]bool
+	now   func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+func newResponseCache(ttl time.Duration, paths []string) *responseCache {
+	c := &responseCache{
+		ttl:     ttl,
+		paths:   make(map[string]bool, len(paths)),
+		now:     time.Now,
+		entries: make(map[string]*cachedResponse),
+	}
+	for _, p := range paths {
+		c.paths[p] = true
+	}
+	return c
+}
+
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (c *responseCache) put(key string, entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.expires = c.now().Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// captureWriter buffers a handler's response so it can be cached before
+// being sent.
+type captureWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) Header() http.Header { return w.header }
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match value matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheMiddleware serves configured GET routes from memory for the cache
+// TTL, with ETag/If-None-Match support. Only 200 responses are cached.
+func (r *Router) cacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.cache == nil || req.Method != http.MethodGet || !r.cache.paths[req.URL.Path] {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		key := req.Method + " " + req.URL.Path
+		entry := r.cache.get(key)
+		if entry == nil {
+			capture := &captureWriter{header: make(http.Header)}
+			next.ServeHTTP(capture, req)
+
+			if capture.status != http.StatusOK {
+				writeCaptured(w, capture)
+				return
+			}
+
+			entry = &cachedResponse{
+				header: capture.header,
+				body:   capture.body.Bytes(),
+				etag:   etagFor(capture.body.Bytes()),
+			}
+			r.cache.put(key, entry)
+		}
+
+		for k, v := range entry.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", entry.etag)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(r.cache.ttl.Seconds())))
+
+		if etagMatches(req.Header.Get("If-None-Match"), entry.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(entry.body); err != nil {
+			r.logger.Error("failed to write cached response", "error", err)
+		}
+	})
+}
+
+func writeCaptured(w http.ResponseWriter, capture *captureWriter) {
+	for k, v := range capture.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(capture.status)
+	w.Write(capture.body.Bytes())
+}
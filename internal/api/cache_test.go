@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func newCacheTestHandler(t *testing.T, status int) (http.Handler, *int, *time.Time) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithResponseCache(time.Minute, "/version"))
+
+	now := time.Now()
+	router.cache.now = func() time.Time { return now }
+
+	calls := 0
+	handler := router.cacheMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"version":"1.0.0"}`))
+	}))
+
+	return handler, &calls, &now
+}
+
+func TestCacheMiddleware_Hit(t *testing.T) {
+	handler, calls, _ := newCacheTestHandler(t, http.StatusOK)
+
+	var etag string
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status 200, got %d", i, w.Code)
+		}
+		if w.Body.String() != `{"version":"1.0.0"}` {
+			t.Errorf("request %d: unexpected body %s", i, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("request %d: expected cached Content-Type, got %q", i, w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Cache-Control") != "public, max-age=60" {
+			t.Errorf("request %d: unexpected Cache-Control %q", i, w.Header().Get("Cache-Control"))
+		}
+		if etag == "" {
+			etag = w.Header().Get("ETag")
+		} else if w.Header().Get("ETag") != etag {
+			t.Errorf("request %d: ETag changed from %s to %s", i, etag, w.Header().Get("ETag"))
+		}
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected handler called once, got %d", *calls)
+	}
+}
+
+func TestCacheMiddleware_TTLExpiry(t *testing.T) {
+	handler, calls, now := newCacheTestHandler(t, http.StatusOK)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	*now = now.Add(30 * time.Second)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	if *calls != 1 {
+		t.Fatalf("expected cached response within TTL, handler called %d times", *calls)
+	}
+
+	*now = now.Add(31 * time.Second)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	if *calls != 2 {
+		t.Errorf("expected handler called again after TTL, got %d calls", *calls)
+	}
+}
+
+func TestCacheMiddleware_ConditionalRequest(t *testing.T) {
+	handler, _, _ := newCacheTestHandler(t, http.StatusOK)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	tests := []struct {
+		name           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{name: "matching etag", ifNoneMatch: etag, expectedStatus: http.StatusNotModified},
+		{name: "weak matching etag", ifNoneMatch: "W/" + etag, expectedStatus: http.StatusNotModified},
+		{name: "one of several", ifNoneMatch: `"other", ` + etag, expectedStatus: http.StatusNotModified},
+		{name: "stale etag", ifNoneMatch: `"stale"`, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/version", nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("expected empty body for 304, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCacheMiddleware_Bypass(t *testing.T) {
+	handler, calls, _ := newCacheTestHandler(t, http.StatusOK)
+
+	// Unconfigured paths and non-GET methods are never cached
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodHead, "/version", nil))
+	}
+	if *calls != 4 {
+		t.Errorf("expected 4 handler calls, got %d", *calls)
+	}
+}
+
+func TestCacheMiddleware_ErrorsNotCached(t *testing.T) {
+	handler, calls, _ := newCacheTestHandler(t, http.StatusInternalServerError)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500 passed through, got %d", w.Code)
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("expected error responses not to be cached, handler called %d times", *calls)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsPolicy holds the CORS settings applied by corsMiddleware.
+type corsPolicy struct {
+	origins   map[string]bool
+	anyOrigin bool
+	methods   string
+	headers   string
+}
+
+// WithCORS allows cross-origin browser requests from origins, which may
+// include "*" to allow any origin. Preflight requests are answered with
+// methods and headers. No origins disables CORS.
+func WithCORS(origins, methods, headers []string) Option {
+	return func(r *Router) {
+		if len(origins) == 0 {
+			return
+		}
+
+		p := &corsPolicy{
+			origins: make(map[string]bool, len(origins)),
+			methods: strings.Join(methods, ", "),
+			headers: strings.Join(headers, ", "),
+		}
+		for _, origin := range origins {
+			if origin == "*" {
+				p.anyOrigin = true
+			}
+			p.origins[origin] = true
+		}
+		r.cors = p
+	}
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	return p.anyOrigin || p.origins[origin]
+}
+
+// corsMiddleware adds Access-Control-* headers for allowed origins and
+// answers preflight requests with 204 without reaching the handler.
+func (r *Router) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if r.cors == nil || origin == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+
+		if !r.cors.allowed(origin) {
+			if preflight {
+				r.respondError(w, http.StatusForbidden, CodeOriginNotAllowed, "Origin not allowed")
+				return
+			}
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", r.cors.methods)
+			w.Header().Set("Access-Control-Allow-Headers", r.cors.headers)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		origins     []string
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+	}{
+		{
+			name:       "allowed origin",
+			method:     http.MethodGet,
+			origin:     "https://app.example.com",
+			origins:    []string{"https://app.example.com"},
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name:       "disallowed origin",
+			method:     http.MethodGet,
+			origin:     "https://evil.example.com",
+			origins:    []string{"https://app.example.com"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "preflight",
+			method:      http.MethodOptions,
+			origin:      "https://app.example.com",
+			preflight:   true,
+			origins:     []string{"https://app.example.com"},
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://app.example.com",
+			wantMethods: "GET, POST",
+		},
+		{
+			name:       "preflight from disallowed origin",
+			method:     http.MethodOptions,
+			origin:     "https://evil.example.com",
+			preflight:  true,
+			origins:    []string{"https://app.example.com"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wildcard origin",
+			method:     http.MethodGet,
+			origin:     "https://any.example.com",
+			origins:    []string{"*"},
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://any.example.com",
+		},
+		{
+			name:       "cors disabled",
+			method:     http.MethodGet,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h,
+				WithCORS(tt.origins, []string{"GET", "POST"}, []string{"Content-Type"}))
+
+			req := httptest.NewRequest(tt.method, "/api/v1/hello", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+			if tt.preflight && tt.wantStatus == http.StatusNoContent {
+				if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+					t.Errorf("Access-Control-Allow-Headers = %q, want Content-Type", got)
+				}
+				if w.Body.Len() != 0 {
+					t.Errorf("expected empty preflight body, got %q", w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decodeJSON decodes a request body into v, keeping untyped numbers as
+// json.Number so decimal values are not rounded through float64.
+func decodeJSON(body io.Reader, v interface{}) error {
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// maxExponentPadding bounds how many zeros an exponent may add beyond the
+// digits written in the literal, so a tiny input like 1e999999 cannot
+// expand into megabytes of output.
+const maxExponentPadding = 64
+
+// FormatDecimal renders n in plain decimal notation without an exponent,
+// e.g. "1.5e3" becomes "1500" and "12e-4" becomes "0.0012". The digits of
+// n are preserved exactly. Numbers whose exponent would add more than
+// maxExponentPadding zeros are rejected.
+func FormatDecimal(n json.Number) (string, error) {
+	s := n.String()
+
+	// Check the exponent before anything expands it, including big.Rat
+	mantissa := s
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid exponent: %w", err)
+		}
+		exp = e
+		mantissa = s[:i]
+	}
+	if limit := len(mantissa) + maxExponentPadding; exp > limit || exp < -limit {
+		return "", fmt.Errorf("number exponent out of range: %q", s)
+	}
+
+	if _, ok := new(big.Rat).SetString(s); !ok {
+		return "", fmt.Errorf("invalid number: %q", s)
+	}
+
+	sign := ""
+	s = mantissa
+	if strings.HasPrefix(s, "-") {
+		sign = "-"
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	digits := intPart + fracPart
+	point := len(intPart) + exp
+
+	// Pad so the decimal point falls inside the digit string
+	if point < 0 {
+		digits = strings.Repeat("0", -point) + digits
+		point = 0
+	}
+	if point > len(digits) {
+		digits += strings.Repeat("0", point-len(digits))
+	}
+
+	intPart = strings.TrimLeft(digits[:point], "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart = strings.TrimRight(digits[point:], "0")
+
+	if intPart == "0" && fracPart == "" {
+		return "0", nil
+	}
+	if fracPart == "" {
+		return sign + intPart, nil
+	}
+	return sign + intPart + "." + fracPart, nil
+}
+
+// normalizeNumbers rewrites every json.Number within a decoded value into
+// plain decimal notation. Numbers FormatDecimal rejects, such as ones with
+// out-of-range exponents, are left as written.
+func normalizeNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if s, err := FormatDecimal(val); err == nil {
+			return json.Number(s)
+		}
+		return val
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeNumbers(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeNumbers(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "0.30000000000000004", want: "0.30000000000000004"},
+		{input: "12345678901234567890.123456789", want: "12345678901234567890.123456789"},
+		{input: "1e21", want: "1000000000000000000000"},
+		{input: "1.5E3", want: "1500"},
+		{input: "12e-4", want: "0.0012"},
+		{input: "-2.50e-1", want: "-0.25"},
+		{input: "100", want: "100"},
+		{input: "0.000", want: "0"},
+		{input: "abc", wantErr: true},
+		{input: "1e64", want: "1" + strings.Repeat("0", 64)},
+		{input: "1e999999", wantErr: true},
+		{input: "-1.5e-999999", wantErr: true},
+		{input: "1e99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := FormatDecimal(json.Number(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatDecimal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatDecimal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_EchoPreservesDecimals(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	body := `{"a":0.1,"b":0.2,"sum":0.30000000000000004,"large":12345678901234567890.12,"huge":1e21,"items":[2.5e-3]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	got := strings.TrimSpace(w.Body.String())
+	want := `{"a":0.1,"b":0.2,"huge":1000000000000000000000,"items":[0.0025],"large":12345678901234567890.12,"sum":0.30000000000000004}`
+	if got != want {
+		t.Errorf("echo response = %s, want %s", got, want)
+	}
+}
+
+func TestRouter_EchoLeavesHugeExponents(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	body := `{"big":1e999999999,"small":-1e-999999999}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != body {
+		t.Errorf("echo response = %s, want %s", got, body)
+	}
+}
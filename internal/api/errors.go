@@ -0,0 +1,56 @@
+package api
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable identifier carried in the code
+// field of every JSON error response. Clients should branch on the code
+// rather than the human-readable message, which may change. Codes are
+// never renamed or reused; new ones may be added.
+type ErrorCode string
+
+const (
+	// CodeInvalidJSON: the request body is not valid JSON (400).
+	CodeInvalidJSON ErrorCode = "INVALID_JSON"
+	// CodeInvalidEncoding: a Content-Encoding body could not be decoded (400).
+	CodeInvalidEncoding ErrorCode = "INVALID_ENCODING"
+	// CodeValidationFailed: the body is well-formed but a value is
+	// missing or invalid (400).
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	// CodeUnauthorized: an admin endpoint was called without the admin
+	// token (401).
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	// CodeOriginNotAllowed: a CORS preflight came from an origin that is
+	// not allowed (403).
+	CodeOriginNotAllowed ErrorCode = "ORIGIN_NOT_ALLOWED"
+	// CodeNotFound: the requested resource does not exist (404).
+	CodeNotFound ErrorCode = "NOT_FOUND"
+	// CodeMethodNotAllowed: the path does not support the request method;
+	// the Allow header lists the methods it does (405).
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	// CodeNotAcceptable: the Accept header rules out JSON (406).
+	CodeNotAcceptable ErrorCode = "NOT_ACCEPTABLE"
+	// CodeBodyTooLarge: the request body exceeds the size limit (413).
+	CodeBodyTooLarge ErrorCode = "BODY_TOO_LARGE"
+	// CodeURITooLong: the request URI or query string is too long (414).
+	CodeURITooLong ErrorCode = "URI_TOO_LONG"
+	// CodeRateLimited: the caller exceeded its rate limit (429).
+	CodeRateLimited ErrorCode = "RATE_LIMITED"
+	// CodeInternal: the server failed unexpectedly (500).
+	CodeInternal ErrorCode = "INTERNAL_ERROR"
+	// CodeOverloaded: the request was shed under load (503).
+	CodeOverloaded ErrorCode = "OVERLOADED"
+	// CodeTimeout: the request did not complete within the request
+	// timeout (503).
+	CodeTimeout ErrorCode = "TIMEOUT"
+)
+
+// ErrorResponse is the body of JSON error responses.
+type ErrorResponse struct {
+	Error string    `json:"error"`
+	Code  ErrorCode `json:"code"`
+}
+
+// respondError writes an ErrorResponse with status.
+func (r *Router) respondError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	r.respondJSON(w, status, ErrorResponse{Error: message, Code: code})
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       func(t *testing.T) io.Reader
+		headers    map[string]string
+		opts       []Option
+		repeat     int
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{
+			name:       "invalid json",
+			method:     http.MethodPut,
+			target:     "/api/v1/admin/log-level",
+			body:       text("{not json"),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidJSON,
+		},
+		{
+			name:       "invalid log level",
+			method:     http.MethodPut,
+			target:     "/api/v1/admin/log-level",
+			body:       text(`{"level":"trace"}`),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+		},
+		{
+			name:       "missing sample rate",
+			method:     http.MethodPut,
+			target:     "/api/v1/admin/logging",
+			body:       text(`{}`),
+			headers:    map[string]string{"Authorization": "Bearer admin-secret"},
+			opts:       []Option{WithAdminToken("admin-secret")},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+		},
+		{
+			name:       "missing admin token",
+			method:     http.MethodPut,
+			target:     "/api/v1/admin/logging",
+			body:       text(`{"sample_rate":0.5}`),
+			opts:       []Option{WithAdminToken("admin-secret")},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeUnauthorized,
+		},
+		{
+			name:       "invalid gzip",
+			method:     http.MethodPost,
+			target:     "/api/v1/echo",
+			body:       text("not gzip"),
+			headers:    map[string]string{"Content-Encoding": "gzip"},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidEncoding,
+		},
+		{
+			name:   "body too large",
+			method: http.MethodPost,
+			target: "/api/v1/echo",
+			body: func(t *testing.T) io.Reader {
+				return gzipBody(t, []byte(`{"data":"`+strings.Repeat("a", 100)+`"}`))
+			},
+			headers:    map[string]string{"Content-Encoding": "gzip"},
+			opts:       []Option{WithMaxBodyBytes(16)},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   CodeBodyTooLarge,
+		},
+		{
+			name:       "not acceptable",
+			method:     http.MethodGet,
+			target:     "/api/v1/hello",
+			headers:    map[string]string{"Accept": "text/html"},
+			wantStatus: http.StatusNotAcceptable,
+			wantCode:   CodeNotAcceptable,
+		},
+		{
+			name:       "uri too long",
+			method:     http.MethodGet,
+			target:     "/api/v1/hello?q=" + strings.Repeat("a", 100),
+			opts:       []Option{WithURLLimits(8192, 10)},
+			wantStatus: http.StatusRequestURITooLong,
+			wantCode:   CodeURITooLong,
+		},
+		{
+			name:       "rate limited",
+			method:     http.MethodGet,
+			target:     "/api/v1/hello",
+			opts:       []Option{WithRateLimit("", RateLimit{Rate: 0.001, Burst: 1}, nil)},
+			repeat:     2,
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   CodeRateLimited,
+		},
+		{
+			name:       "origin not allowed",
+			method:     http.MethodOptions,
+			target:     "/api/v1/hello",
+			headers:    map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"},
+			opts:       []Option{WithCORS([]string{"https://app.example.com"}, nil, nil)},
+			wantStatus: http.StatusForbidden,
+			wantCode:   CodeOriginNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, tt.opts...)
+
+			var w *httptest.ResponseRecorder
+			for i := 0; i < max(tt.repeat, 1); i++ {
+				var body io.Reader
+				if tt.body != nil {
+					body = tt.body(t)
+				}
+				req := httptest.NewRequest(tt.method, tt.target, body)
+				for k, v := range tt.headers {
+					req.Header.Set(k, v)
+				}
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+			if resp.Error == "" {
+				t.Error("expected a human-readable error message")
+			}
+		})
+	}
+}
+
+func TestErrorCodes_Panic(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	router := NewRouter(logger, health.New(&mockChecker{}, &mockChecker{}))
+	router.mux.HandleFunc("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != CodeInternal {
+		t.Errorf("code = %q, want %q", resp.Code, CodeInternal)
+	}
+}
+
+// text returns a body func producing s.
+func text(s string) func(t *testing.T) io.Reader {
+	return func(t *testing.T) io.Reader {
+		return strings.NewReader(s)
+	}
+}
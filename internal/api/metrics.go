@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests that matched no registered pattern, so
+// arbitrary paths cannot grow label cardinality.
+const unmatchedRoute = "unmatched"
+
+// sizeBuckets spans 64B to 4MB.
+var sizeBuckets = prometheus.ExponentialBuckets(64, 4, 9)
+
+// httpMetrics holds the HTTP metrics registered by WithMetrics.
+type httpMetrics struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	inFlight     prometheus.Gauge
+	duration     *prometheus.HistogramVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+func newHTTPMetrics(reg *prometheus.Registry) *httpMetrics {
+	m := &httpMetrics{
+		registry: reg,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests handled.",
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being handled.",
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "Size of HTTP request bodies from Content-Length.",
+			Buckets: sizeBuckets,
+		}, []string{"route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies written.",
+			Buckets: sizeBuckets,
+		}, []string{"route"}),
+	}
+	reg.MustRegister(m.requests, m.inFlight, m.duration, m.requestSize, m.responseSize)
+	return m
+}
+
+// WithMetrics records HTTP metrics on reg and serves it at /metrics.
+// Tests can pass a fresh prometheus.NewRegistry(). A nil registry disables
+// metrics.
+func WithMetrics(reg *prometheus.Registry) Option {
+	return func(r *Router) {
+		if reg != nil {
+			r.metrics = newHTTPMetrics(reg)
+		}
+	}
+}
+
+// metricsWriter records the status code and body bytes of a response.
+type metricsWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *metricsWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *metricsWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *metricsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// route returns the path of the mux pattern req will be routed to,
+// without the method.
+func (r *Router) route(req *http.Request) string {
+	_, pattern := r.mux.Handler(req)
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+	if pattern == "" || pattern == "/" {
+		return unmatchedRoute
+	}
+	return pattern
+}
+
+// metricsMiddleware records request count, latency, in-flight requests
+// and request/response sizes per route pattern when metrics are enabled.
+func (r *Router) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.metrics == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		m := r.metrics
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		route := r.route(req)
+		if req.ContentLength >= 0 {
+			m.requestSize.WithLabelValues(route).Observe(float64(req.ContentLength))
+		}
+
+		start := time.Now()
+		mw := &metricsWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(mw, req)
+
+		status := strconv.Itoa(mw.status)
+		m.requests.WithLabelValues(req.Method, route, status).Inc()
+		m.duration.WithLabelValues(req.Method, route, status).Observe(time.Since(start).Seconds())
+		m.responseSize.WithLabelValues(route).Observe(float64(mw.bytes))
+	})
+}
+
+// metricsHandler serves the metrics registry in Prometheus format.
+func (r *Router) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(r.metrics.registry, promhttp.HandlerOpts{})
+}
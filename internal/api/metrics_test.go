@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestMetricsMiddleware_ObservesSizes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	reg := prometheus.NewRegistry()
+	router := NewRouter(logger, h, WithMetrics(reg))
+
+	body := `{"message":"hello"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	tests := []struct {
+		name string
+		want int
+	}{
+		{name: "http_request_size_bytes", want: len(body)},
+		{name: "http_response_size_bytes", want: w.Body.Len()},
+	}
+	for _, tt := range tests {
+		count, sum := histogramSample(t, reg, tt.name, "/api/v1/echo")
+		if count != 1 {
+			t.Errorf("%s count = %d, want 1", tt.name, count)
+		}
+		if int(sum) != tt.want {
+			t.Errorf("%s sum = %v, want %d", tt.name, sum, tt.want)
+		}
+	}
+}
+
+// histogramSample returns the sample count and sum of the named histogram
+// for route.
+func histogramSample(t *testing.T, reg *prometheus.Registry, name, route string) (uint64, float64) {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if m.GetLabel()[0].GetValue() == route {
+				return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+	t.Fatalf("no %s sample for route %q", name, route)
+	return 0, 0
+}
+
+func TestMetricsMiddleware_UnmatchedRoute(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	reg := prometheus.NewRegistry()
+	router := NewRouter(logger, h, WithMetrics(reg))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/path", nil))
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "route" && l.GetValue() != unmatchedRoute {
+					t.Errorf("%s route = %q, want %q", mf.GetName(), l.GetValue(), unmatchedRoute)
+				}
+			}
+		}
+	}
+}
+
+func TestMetricsMiddleware_Disabled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithMetrics(nil))
+
+	if router.metrics != nil {
+		t.Fatal("metrics enabled with nil registerer")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMetricsMiddleware_RequestsByStatus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	reg := prometheus.NewRegistry()
+	router := NewRouter(logger, h, WithMetrics(reg))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/hello", nil))
+
+	tests := []struct {
+		method string
+		route  string
+		status string
+		want   float64
+	}{
+		{http.MethodGet, "/version", "200", 2},
+		{http.MethodPost, "/api/v1/hello", "405", 1},
+	}
+	for _, tt := range tests {
+		got := counterValue(t, reg, "http_requests_total", map[string]string{
+			"method": tt.method, "route": tt.route, "status": tt.status,
+		})
+		if got != tt.want {
+			t.Errorf("http_requests_total{%s %s %s} = %v, want %v", tt.method, tt.route, tt.status, got, tt.want)
+		}
+	}
+
+	if got := counterValue(t, reg, "http_requests_in_flight", nil); got != 0 {
+		t.Errorf("http_requests_in_flight = %v, want 0", got)
+	}
+}
+
+func TestRouter_MetricsEndpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+
+	router := NewRouter(logger, h, WithMetrics(prometheus.NewRegistry()))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	// Scrapers that only accept the text exposition format must not be
+	// refused by the JSON content negotiation.
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	for _, name := range []string{"http_requests_total", "http_request_duration_seconds", "http_requests_in_flight"} {
+		if !strings.Contains(w.Body.String(), name) {
+			t.Errorf("/metrics output missing %s", name)
+		}
+	}
+
+	disabled := NewRouter(logger, h)
+	w = httptest.NewRecorder()
+	disabled.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status with metrics disabled = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// counterValue returns the value of the counter or gauge sample of name
+// whose labels match labels.
+func counterValue(t *testing.T, reg *prometheus.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("no %s sample with labels %v", name, labels)
+	return 0
+}
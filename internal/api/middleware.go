@@ -0,0 +1,172 @@
+package api
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/sksmith/go-base-ms/internal/kafka"
+)
+
+// logMiddleware logs each request as it arrives.
+func (r *Router) logMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attrs := []any{
+			"method", req.Method,
+			"path", req.URL.Path,
+			"remote_addr", req.RemoteAddr,
+			"proto", req.Proto,
+		}
+		if req.TLS != nil {
+			attrs = append(attrs,
+				"tls_version", tls.VersionName(req.TLS.Version),
+				"tls_cipher", tls.CipherSuiteName(req.TLS.CipherSuite),
+			)
+		}
+		r.logger.Info("request", attrs...)
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// recoverMiddleware converts handler panics into 500 responses so a single
+// bad request cannot take down the connection, and records them for the
+// panic liveness signal when enabled.
+func (r *Router) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			if r.panics != nil {
+				r.panics.record()
+			}
+
+			r.logger.Error("panic recovered",
+				"method", req.Method,
+				"path", req.URL.Path,
+				"panic", rec,
+				"stack", string(debug.Stack()))
+
+			r.respondError(w, http.StatusInternalServerError, CodeInternal, "internal server error")
+		}()
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// bodyLimitMiddleware caps request bodies at maxBodyBytes. Requests that
+// declare a larger Content-Length are rejected up front; others fail with
+// a *http.MaxBytesError once a handler reads past the limit.
+func (r *Router) bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ContentLength > r.maxBodyBytes {
+			r.respondError(w, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+			return
+		}
+
+		req.Body = http.MaxBytesReader(w, req.Body, r.maxBodyBytes)
+		next.ServeHTTP(w, req)
+	})
+}
+
+// decompressMiddleware transparently decompresses gzip-encoded request
+// bodies. The decompressed stream is capped at maxBodyBytes so a small
+// compressed payload cannot expand without bound.
+func (r *Router) decompressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			// A body of unknown length can hit the size limit while the
+			// gzip header is still being read
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				r.respondError(w, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+				return
+			}
+			r.respondError(w, http.StatusBadRequest, CodeInvalidEncoding, "Invalid gzip body")
+			return
+		}
+		defer gz.Close()
+
+		req.Body = http.MaxBytesReader(w, gz, r.maxBodyBytes)
+		req.Header.Del("Content-Encoding")
+		req.ContentLength = -1
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// TrailingSlashMode controls routing of paths that end in a slash.
+type TrailingSlashMode string
+
+const (
+	// TrailingSlashStrict routes paths exactly as requested.
+	TrailingSlashStrict TrailingSlashMode = "strict"
+	// TrailingSlashStrip removes the trailing slash before routing.
+	TrailingSlashStrip TrailingSlashMode = "strip"
+	// TrailingSlashRedirect answers with a 308 to the path without the slash.
+	TrailingSlashRedirect TrailingSlashMode = "redirect"
+)
+
+func (r *Router) trailingSlashMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		if r.trailingSlash == TrailingSlashStrict || path == "/" || !strings.HasSuffix(path, "/") {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		trimmed := strings.TrimRight(path, "/")
+		if trimmed == "" {
+			trimmed = "/"
+		}
+
+		if r.trailingSlash == TrailingSlashRedirect {
+			target := *req.URL
+			target.Path = trimmed
+			target.RawPath = ""
+			http.Redirect(w, req, target.RequestURI(), http.StatusPermanentRedirect)
+			return
+		}
+
+		req.URL.Path = trimmed
+		req.URL.RawPath = ""
+		next.ServeHTTP(w, req)
+	})
+}
+
+// propagateHeadersMiddleware stores the configured request headers in the
+// request context so that messages produced while handling the request
+// carry them.
+func (r *Router) propagateHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(r.propagate) == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		headers := make(map[string][]byte)
+		for _, name := range r.propagate {
+			if value := req.Header.Get(name); value != "" {
+				headers[name] = []byte(value)
+			}
+		}
+
+		ctx := kafka.ContextWithHeaders(req.Context(), headers)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
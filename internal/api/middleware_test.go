@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+	"github.com/sksmith/go-base-ms/internal/kafka"
+)
+
+func gzipBody(t *testing.T, data []byte) *bytes.Buffer {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("failed to gzip body: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to gzip body: %v", err)
+	}
+	return buf
+}
+
+func TestDecompressMiddleware(t *testing.T) {
+	oversized := `{"data": "` + strings.Repeat("a", 2048) + `"}`
+
+	tests := []struct {
+		name           string
+		body           func(t *testing.T) *bytes.Buffer
+		encoding       string
+		unknownLength  bool
+		expectedStatus int
+	}{
+		{
+			name: "gzip body echoed",
+			body: func(t *testing.T) *bytes.Buffer {
+				return gzipBody(t, []byte(`{"test": "data"}`))
+			},
+			encoding:       "gzip",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "plain body untouched",
+			body: func(t *testing.T) *bytes.Buffer {
+				return bytes.NewBufferString(`{"test": "data"}`)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "oversized decompressed body",
+			body: func(t *testing.T) *bytes.Buffer {
+				return gzipBody(t, []byte(oversized))
+			},
+			encoding:       "gzip",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "oversized compressed body of unknown length",
+			body: func(t *testing.T) *bytes.Buffer {
+				// The gzip header alone exceeds the limit
+				buf := &bytes.Buffer{}
+				gz := gzip.NewWriter(buf)
+				gz.Extra = bytes.Repeat([]byte("a"), 2048)
+				if err := gz.Close(); err != nil {
+					t.Fatalf("failed to gzip body: %v", err)
+				}
+				return buf
+			},
+			encoding:       "gzip",
+			unknownLength:  true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "invalid gzip body",
+			body: func(t *testing.T) *bytes.Buffer {
+				return bytes.NewBufferString(`{"test": "data"}`)
+			},
+			encoding:       "gzip",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithMaxBodyBytes(1024))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", tt.body(t))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if response["test"] != "data" {
+					t.Errorf("expected echoed test=data, got %v", response["test"])
+				}
+			}
+		})
+	}
+}
+
+func TestTrailingSlashMiddleware(t *testing.T) {
+	tests := []struct {
+		name             string
+		mode             TrailingSlashMode
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:           "strict rejects trailing slash",
+			mode:           TrailingSlashStrict,
+			path:           "/api/v1/hello/",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "strip routes trailing slash",
+			mode:           TrailingSlashStrip,
+			path:           "/api/v1/hello/",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "redirect to canonical path",
+			mode:             TrailingSlashRedirect,
+			path:             "/api/v1/hello/?name=test",
+			expectedStatus:   http.StatusPermanentRedirect,
+			expectedLocation: "/api/v1/hello?name=test",
+		},
+		{
+			name:           "canonical path untouched",
+			mode:           TrailingSlashRedirect,
+			path:           "/api/v1/hello",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithTrailingSlash(tt.mode))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if location := w.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("expected Location %q, got %q", tt.expectedLocation, location)
+			}
+		})
+	}
+}
+
+func TestPropagateHeadersMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithPropagateHeaders("X-Request-ID", "traceparent", "X-Tenant-ID"))
+
+	var got map[string][]byte
+	handler := router.propagateHeadersMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = kafka.HeadersFromContext(req.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-Request-ID": "req-123",
+		"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d propagated headers, got %v", len(want), got)
+	}
+	for k, v := range want {
+		if string(got[k]) != v {
+			t.Errorf("propagated header %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+	router.mux.HandleFunc("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("nil map write")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body["error"] != "internal server error" {
+		t.Errorf("expected internal server error, got %q", body["error"])
+	}
+
+	if !strings.Contains(logs.String(), `"panic":"nil map write"`) {
+		t.Errorf("expected panic value in logs, got %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "recoverMiddleware") {
+		t.Error("expected stack trace in logs")
+	}
+
+	// The router keeps serving after a recovered panic
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d after recovery, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRecoverMiddleware_AbortHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+	router.mux.HandleFunc("/abort", func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("expected ErrAbortHandler to propagate, got %v", rec)
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
+
+func TestRouter_UseOrder(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				// The default logging middleware runs before any added one
+				if !strings.Contains(logs.String(), `"msg":"request"`) {
+					t.Errorf("%s ran before the request was logged", name)
+				}
+				order = append(order, name+" before")
+				next.ServeHTTP(w, req)
+				order = append(order, name+" after")
+			})
+		}
+	}
+	router.Use(record("first"), record("second"))
+	router.Use(record("third"))
+	router.mux.HandleFunc("/order", func(w http.ResponseWriter, req *http.Request) {
+		order = append(order, "handler")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/order", nil))
+
+	want := []string{
+		"first before", "second before", "third before",
+		"handler",
+		"third after", "second after", "first after",
+	}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("execution order = %v, want %v", order, want)
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	const limit = 64
+	// {"data":"..."} padded so the body is exactly limit bytes
+	atLimit := `{"data":"` + strings.Repeat("a", limit-len(`{"data":""}`)) + `"}`
+
+	tests := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		wantStatus    int
+	}{
+		{name: "at limit", body: atLimit, wantStatus: http.StatusOK},
+		{name: "one byte over limit", body: atLimit + " ", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "over limit without content length", body: atLimit + " ", unknownLength: true, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithMaxBodyBytes(limit))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge {
+				var resp ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Code != CodeBodyTooLarge {
+					t.Errorf("code = %q, want %q", resp.Code, CodeBodyTooLarge)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// supportedMediaTypes are the response types the API produces.
+var supportedMediaTypes = []string{"application/json"}
+
+// acceptsJSON reports whether an Accept header value permits a JSON
+// response. A missing header accepts anything.
+func acceptsJSON(accept string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(part, ";")
+		mediaRange = strings.ToLower(strings.TrimSpace(mediaRange))
+
+		// q=0 explicitly marks a type as not acceptable
+		if q, ok := qualityParam(params); ok && q == 0 {
+			continue
+		}
+
+		switch mediaRange {
+		case "application/json", "application/*", "*/*":
+			return true
+		}
+	}
+	return false
+}
+
+func qualityParam(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(key, "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, false
+		}
+		return q, true
+	}
+	return 0, false
+}
+
+// negotiationExempt reports whether path serves something other than JSON:
+// health probes, the OpenAPI documents, the HTML API explorer, the
+// Prometheus text exposition and the config .env export.
+func negotiationExempt(path string) bool {
+	switch path {
+	case "/docs", "/metrics", "/api/v1/admin/config.env":
+		return true
+	}
+	return strings.HasPrefix(path, "/health/") || strings.HasPrefix(path, "/openapi.") || strings.HasPrefix(path, "/docs/")
+}
+
+// negotiateMiddleware enforces the JSON-only contract by answering 406 when
+// the client's Accept header rules out JSON. Paths that serve other types
+// are exempt.
+func (r *Router) negotiateMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if negotiationExempt(req.URL.Path) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if !acceptsJSON(req.Header.Get("Accept")) {
+			r.respondJSON(w, http.StatusNotAcceptable, map[string]interface{}{
+				"error":     "Not acceptable",
+				"code":      CodeNotAcceptable,
+				"supported": supportedMediaTypes,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestNegotiateMiddleware(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		accept         string
+		expectedStatus int
+	}{
+		{name: "no accept header", path: "/api/v1/hello", expectedStatus: http.StatusOK},
+		{name: "json", path: "/api/v1/hello", accept: "application/json", expectedStatus: http.StatusOK},
+		{name: "wildcard", path: "/api/v1/hello", accept: "*/*", expectedStatus: http.StatusOK},
+		{name: "application wildcard", path: "/api/v1/hello", accept: "application/*", expectedStatus: http.StatusOK},
+		{name: "json among others", path: "/api/v1/hello", accept: "text/html, application/json;q=0.9", expectedStatus: http.StatusOK},
+		{name: "xml only", path: "/api/v1/hello", accept: "application/xml", expectedStatus: http.StatusNotAcceptable},
+		{name: "json refused with q=0", path: "/api/v1/hello", accept: "application/xml, application/json;q=0", expectedStatus: http.StatusNotAcceptable},
+		{name: "health exempt", path: "/health/live", accept: "application/xml", expectedStatus: http.StatusOK},
+		{name: "metrics exempt", path: "/metrics", accept: "text/plain", expectedStatus: http.StatusOK},
+		{name: "config env exempt", path: "/api/v1/admin/config.env", accept: "text/plain", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h,
+				WithMetrics(prometheus.NewRegistry()),
+				WithConfig(cfg),
+				WithAdminToken("admin-secret"),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusNotAcceptable {
+				var response struct {
+					Error     string   `json:"error"`
+					Supported []string `json:"supported"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(response.Supported) != 1 || response.Supported[0] != "application/json" {
+					t.Errorf("expected supported [application/json], got %v", response.Supported)
+				}
+			}
+		})
+	}
+}
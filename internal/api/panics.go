@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// panicTracker counts recovered panics within a sliding window so that a
+// process stuck panicking can report itself as not live.
+type panicTracker struct {
+	threshold int
+	window    time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	panics []time.Time
+}
+
+func newPanicTracker(threshold int, window time.Duration) *panicTracker {
+	return &panicTracker{
+		threshold: threshold,
+		window:    window,
+		now:       time.Now,
+	}
+}
+
+func (p *panicTracker) record() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.panics = append(p.panics, p.now())
+	p.prune()
+}
+
+// check is a health.LivenessSignal failing once threshold panics were
+// recovered within the window.
+func (p *panicTracker) check() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune()
+	if len(p.panics) >= p.threshold {
+		return fmt.Errorf("%d panics recovered in the last %s", len(p.panics), p.window)
+	}
+	return nil
+}
+
+func (p *panicTracker) prune() {
+	cutoff := p.now().Add(-p.window)
+	i := 0
+	for i < len(p.panics) && !p.panics[i].After(cutoff) {
+		i++
+	}
+	p.panics = p.panics[i:]
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestPanicTracker_Window(t *testing.T) {
+	now := time.Unix(0, 0)
+	p := newPanicTracker(2, time.Minute)
+	p.now = func() time.Time { return now }
+
+	p.record()
+	if err := p.check(); err != nil {
+		t.Fatalf("check() error = %v after one panic, want nil", err)
+	}
+
+	p.record()
+	if err := p.check(); err == nil {
+		t.Fatal("check() should fail once the threshold is reached")
+	}
+
+	now = now.Add(time.Minute)
+	if err := p.check(); err != nil {
+		t.Errorf("check() error = %v after the window elapsed, want nil", err)
+	}
+}
+
+func TestRouter_PanicsFlipLiveness(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithPanicThreshold(3, time.Minute))
+	router.mux.HandleFunc("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("handler bug")
+	})
+
+	liveness := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := liveness(); code != http.StatusOK {
+			t.Fatalf("expected liveness 200 after %d panics, got %d", i, code)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected panic to return 500, got %d", w.Code)
+		}
+	}
+
+	if code := liveness(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected liveness 503 after repeated panics, got %d", code)
+	}
+}
+
+func TestRouter_PanicThresholdDisabled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+	router.mux.HandleFunc("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("handler bug")
+	})
+
+	for i := 0; i < 10; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected liveness 200 with panic check disabled, got %d", w.Code)
+	}
+}
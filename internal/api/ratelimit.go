@@ -0,0 +1,160 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateLimitKeys bounds the bucket map. Once it is reached idle buckets
+// are evicted, and if none are idle the least recently used one is.
+const maxRateLimitKeys = 10000
+
+// RateLimit is a sustained rate in requests per second with a burst size.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// rateLimiter keeps a token bucket per key, where the key is a tenant with
+// a configured override, named by a request header (e.g. X-Tenant-ID), or
+// else the client IP.
+type rateLimiter struct {
+	header    string
+	limit     RateLimit
+	overrides map[string]RateLimit
+	now       func() time.Time
+	maxKeys   int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(header string, limit RateLimit, overrides map[string]RateLimit) *rateLimiter {
+	return &rateLimiter{
+		header:    header,
+		limit:     limit,
+		overrides: overrides,
+		now:       time.Now,
+		maxKeys:   maxRateLimitKeys,
+		buckets:   make(map[string]*tokenBucket),
+	}
+}
+
+// tenant returns the tenant named by the configured header, or "" when
+// there is none.
+func (l *rateLimiter) tenant(req *http.Request) string {
+	if l.header == "" {
+		return ""
+	}
+	return req.Header.Get(l.header)
+}
+
+// key identifies the caller. Only tenants with an override get a bucket
+// of their own, since the header is client-controlled; tenant keys are
+// namespaced so a tenant named like an IP cannot share its bucket.
+func (l *rateLimiter) key(req *http.Request) (string, RateLimit) {
+	if tenant := l.tenant(req); tenant != "" {
+		if limit, ok := l.overrides[tenant]; ok {
+			return "tenant:" + tenant, limit
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host, l.limit
+}
+
+// allow takes a token for key, returning false and the wait until the next
+// token when the bucket is empty.
+func (l *rateLimiter) allow(key string, limit RateLimit) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.maxKeys {
+			l.evictIdle(now)
+		}
+		if len(l.buckets) >= l.maxKeys {
+			l.evictOldest()
+		}
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed*limit.Rate)
+	bucket.last = now
+	bucket.lastSeen = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// evictIdle drops buckets that have had time to refill completely; they
+// are indistinguishable from new ones.
+func (l *rateLimiter) evictIdle(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// evictOldest drops the least recently used bucket.
+func (l *rateLimiter) evictOldest() {
+	var oldest string
+	var oldestSeen time.Time
+	for key, bucket := range l.buckets {
+		if oldest == "" || bucket.lastSeen.Before(oldestSeen) {
+			oldest, oldestSeen = key, bucket.lastSeen
+		}
+	}
+	delete(l.buckets, oldest)
+}
+
+// rateLimitMiddleware answers 429 once a caller exceeds its limit and
+// records the tenant named by the rate limit header on the request's
+// RequestContext. Health endpoints are exempt.
+func (r *Router) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.limiter == nil || strings.HasPrefix(req.URL.Path, "/health/") {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if tenant := r.limiter.tenant(req); tenant != "" {
+			var rc *RequestContext
+			req, rc = withRequestContext(req)
+			rc.SetTenant(tenant)
+		}
+
+		key, limit := r.limiter.key(req)
+		if ok, wait := r.limiter.allow(key, limit); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			r.respondError(w, http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestRateLimitMiddleware_TenantBuckets(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithRateLimit("X-Tenant-ID",
+		RateLimit{Rate: 1, Burst: 2},
+		map[string]RateLimit{"premium": {Rate: 1, Burst: 5}},
+	))
+
+	serve := func(tenant, remoteAddr, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Tenant A exhausts the default burst of 2
+	for i := 0; i < 2; i++ {
+		if code := serve("tenant-a", "10.0.0.1:1234", "/api/v1/hello"); code != http.StatusOK {
+			t.Fatalf("tenant-a request %d: expected 200, got %d", i, code)
+		}
+	}
+	if code := serve("tenant-a", "10.0.0.1:1234", "/api/v1/hello"); code != http.StatusTooManyRequests {
+		t.Fatalf("tenant-a: expected 429 after burst, got %d", code)
+	}
+
+	// Another tenant without an override from the same IP shares the IP
+	// bucket, so rotating the header does not escape the limit
+	if code := serve("tenant-b", "10.0.0.1:1234", "/api/v1/hello"); code != http.StatusTooManyRequests {
+		t.Errorf("tenant-b: expected 429 from the shared IP bucket, got %d", code)
+	}
+	if code := serve("", "10.0.0.1:1234", "/api/v1/hello"); code != http.StatusTooManyRequests {
+		t.Errorf("no tenant: expected 429 from the shared IP bucket, got %d", code)
+	}
+
+	// Overridden tenant gets the larger burst
+	for i := 0; i < 5; i++ {
+		if code := serve("premium", "10.0.0.2:1234", "/api/v1/hello"); code != http.StatusOK {
+			t.Fatalf("premium request %d: expected 200, got %d", i, code)
+		}
+	}
+	if code := serve("premium", "10.0.0.2:1234", "/api/v1/hello"); code != http.StatusTooManyRequests {
+		t.Errorf("premium: expected 429 after override burst, got %d", code)
+	}
+
+	// Health endpoints are never limited
+	if code := serve("tenant-a", "10.0.0.1:1234", "/health/live"); code != http.StatusOK {
+		t.Errorf("expected health endpoint to bypass rate limit, got %d", code)
+	}
+}
+
+func TestRateLimitMiddleware_IPFallback(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithRateLimit("X-Tenant-ID", RateLimit{Rate: 1, Burst: 1}, nil))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("10.0.0.1:1111"); w.Code != http.StatusOK {
+		t.Fatalf("expected first request 200, got %d", w.Code)
+	}
+	// Same IP on a different port shares the bucket
+	w := serve("10.0.0.1:2222")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for same IP, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+	}
+
+	if w := serve("10.0.0.2:1111"); w.Code != http.StatusOK {
+		t.Errorf("expected other IP to get default limit, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter("", RateLimit{Rate: 2, Burst: 1}, nil)
+	l.now = func() time.Time { return now }
+
+	if ok, _ := l.allow("k", l.limit); !ok {
+		t.Fatal("expected first request allowed")
+	}
+	ok, wait := l.allow("k", l.limit)
+	if ok {
+		t.Fatal("expected second request rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected wait 500ms, got %v", wait)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("k", l.limit); !ok {
+		t.Error("expected request allowed after refill")
+	}
+}
+
+func TestRateLimitMiddleware_SetsTenant(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithRateLimit("X-Tenant-ID", RateLimit{Rate: 1, Burst: 5}, nil))
+
+	tests := []struct {
+		name   string
+		tenant string
+	}{
+		{name: "tenant header", tenant: "acme"},
+		{name: "no tenant header", tenant: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := router.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				got = RequestContextFrom(req.Context()).Tenant()
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.tenant {
+				t.Errorf("Tenant() = %q, want %q", got, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_MaxKeys(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter("", RateLimit{Rate: 1, Burst: 1}, nil)
+	l.now = func() time.Time { return now }
+	l.maxKeys = 3
+
+	// Every bucket stays active, so none is idle enough to evict
+	for i := 0; i < 10; i++ {
+		now = now.Add(time.Millisecond)
+		l.allow(fmt.Sprintf("k%d", i), l.limit)
+	}
+
+	if len(l.buckets) != l.maxKeys {
+		t.Errorf("buckets = %d, want the cap of %d", len(l.buckets), l.maxKeys)
+	}
+	for _, key := range []string{"k7", "k8", "k9"} {
+		if _, ok := l.buckets[key]; !ok {
+			t.Errorf("expected recently used bucket %s to be kept", key)
+		}
+	}
+}
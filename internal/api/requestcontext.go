@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type requestContextKey struct{}
+
+// RequestContext holds the per-request values shared between middleware
+// and handlers. It is stored once in the request context; use
+// RequestContextFrom to reach it rather than adding new context keys.
+// Getters on a nil RequestContext return zero values so code running
+// outside a request needs no special casing.
+type RequestContext struct {
+	mu        sync.RWMutex
+	requestID string
+	principal string
+	tenant    string
+	timings   *serverTimings
+}
+
+// RequestContextFrom returns the RequestContext of the current request, or
+// nil outside a request.
+func RequestContextFrom(ctx context.Context) *RequestContext {
+	rc, _ := ctx.Value(requestContextKey{}).(*RequestContext)
+	return rc
+}
+
+// withRequestContext returns req's RequestContext, attaching a new one to
+// the returned request when it has none yet.
+func withRequestContext(req *http.Request) (*http.Request, *RequestContext) {
+	if rc := RequestContextFrom(req.Context()); rc != nil {
+		return req, rc
+	}
+	rc := &RequestContext{}
+	return req.WithContext(context.WithValue(req.Context(), requestContextKey{}, rc)), rc
+}
+
+// RequestID returns the ID assigned to the request.
+func (rc *RequestContext) RequestID() string {
+	if rc == nil {
+		return ""
+	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.requestID
+}
+
+func (rc *RequestContext) SetRequestID(requestID string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requestID = requestID
+}
+
+// Principal returns the authenticated caller, or "" for anonymous requests.
+func (rc *RequestContext) Principal() string {
+	if rc == nil {
+		return ""
+	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.principal
+}
+
+func (rc *RequestContext) SetPrincipal(principal string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.principal = principal
+}
+
+// Tenant returns the tenant the request acts on behalf of, as named by the
+// rate limit header.
+func (rc *RequestContext) Tenant() string {
+	if rc == nil {
+		return ""
+	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.tenant
+}
+
+func (rc *RequestContext) SetTenant(tenant string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.tenant = tenant
+}
+
+// timingCollector returns the Server-Timing collector, or nil when
+// Server-Timing is disabled.
+func (rc *RequestContext) timingCollector() *serverTimings {
+	if rc == nil {
+		return nil
+	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.timings
+}
+
+func (rc *RequestContext) setTimingCollector(timings *serverTimings) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.timings = timings
+}
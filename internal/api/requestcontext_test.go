@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestRequestContext_SetGet(t *testing.T) {
+	req, rc := withRequestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	rc.SetRequestID("req-1")
+	rc.SetPrincipal("alice")
+	rc.SetTenant("acme")
+
+	got := RequestContextFrom(req.Context())
+	if got != rc {
+		t.Fatal("RequestContextFrom() did not return the attached RequestContext")
+	}
+	if got.RequestID() != "req-1" {
+		t.Errorf("RequestID() = %q, want %q", got.RequestID(), "req-1")
+	}
+	if got.Principal() != "alice" {
+		t.Errorf("Principal() = %q, want %q", got.Principal(), "alice")
+	}
+	if got.Tenant() != "acme" {
+		t.Errorf("Tenant() = %q, want %q", got.Tenant(), "acme")
+	}
+
+	// Attaching again reuses the existing RequestContext
+	again, rc2 := withRequestContext(req)
+	if again != req || rc2 != rc {
+		t.Error("withRequestContext() replaced an existing RequestContext")
+	}
+}
+
+func TestRequestContext_OutsideRequest(t *testing.T) {
+	rc := RequestContextFrom(context.Background())
+	if rc != nil {
+		t.Fatalf("RequestContextFrom() = %v, want nil", rc)
+	}
+	if rc.RequestID() != "" || rc.Principal() != "" || rc.Tenant() != "" {
+		t.Error("expected zero values from a nil RequestContext")
+	}
+	if got := RequestIDFromContext(context.Background()); got != "" {
+		t.Errorf("RequestIDFromContext() = %q, want empty", got)
+	}
+}
+
+func TestRequestContext_SharedAcrossMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := NewRouter(logger, health.New(&mockChecker{}, &mockChecker{}))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			RequestContextFrom(req.Context()).SetTenant("acme")
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	var requestID, tenant string
+	router.mux.HandleFunc("/ctx", func(w http.ResponseWriter, req *http.Request) {
+		rc := RequestContextFrom(req.Context())
+		requestID, tenant = rc.RequestID(), rc.Tenant()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ctx", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if requestID != "req-42" {
+		t.Errorf("RequestID() = %q, want %q", requestID, "req-42")
+	}
+	if tenant != "acme" {
+		t.Errorf("Tenant() = %q, want %q", tenant, "acme")
+	}
+}
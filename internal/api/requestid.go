@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/sksmith/go-base-ms/internal/logger"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDFromContext returns the ID assigned to the current request, or ""
+// outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	return RequestContextFrom(ctx).RequestID()
+}
+
+// requestIDMiddleware reuses the caller's X-Request-ID or generates one,
+// echoing it on the response and making it available to handlers, and to
+// their logs through logger.WithContext. The header is also set on the
+// request so it can be propagated downstream.
+func (r *Router) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestID := req.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = r.ids.New()
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		req, rc := withRequestContext(req)
+		rc.SetRequestID(requestID)
+		req = req.WithContext(logger.ContextWith(req.Context(), slog.String("request_id", requestID)))
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+	"github.com/sksmith/go-base-ms/internal/logger"
+)
+
+type staticIDs struct{ id string }
+
+func (s staticIDs) New() string { return s.id }
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		want     string
+	}{
+		{name: "generates id", want: "generated-id"},
+		{name: "keeps caller id", incoming: "caller-id", want: "caller-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithIDGenerator(staticIDs{id: "generated-id"}))
+
+			var fromContext, fromHeader string
+			handler := router.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fromContext = RequestIDFromContext(req.Context())
+				fromHeader = req.Header.Get(RequestIDHeader)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get(RequestIDHeader); got != tt.want {
+				t.Errorf("response %s = %q, want %q", RequestIDHeader, got, tt.want)
+			}
+			if fromContext != tt.want {
+				t.Errorf("RequestIDFromContext() = %q, want %q", fromContext, tt.want)
+			}
+			if fromHeader != tt.want {
+				t.Errorf("request %s = %q, want %q", RequestIDHeader, fromHeader, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddleware_LogContext(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+	buf := &bytes.Buffer{}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+
+	log := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(log, h, WithIDGenerator(staticIDs{id: "generated-id"}))
+
+	handler := router.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger.WithContext(req.Context()).Info("handling")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil))
+
+	if !strings.Contains(buf.String(), `"request_id":"generated-id"`) {
+		t.Errorf("handler log %q missing request_id", buf.String())
+	}
+}
@@ -1,76 +1,343 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/sksmith/go-base-ms/internal/config"
 	"github.com/sksmith/go-base-ms/internal/health"
+	"github.com/sksmith/go-base-ms/internal/id"
 	"github.com/sksmith/go-base-ms/internal/logger"
 	"github.com/sksmith/go-base-ms/internal/version"
 )
 
+// defaultMaxBodyBytes caps request bodies when no limit is configured.
+const defaultMaxBodyBytes = 1 << 20 // 1MB
+
+// Middleware wraps a handler with cross-cutting behavior.
+type Middleware func(http.Handler) http.Handler
+
 type Router struct {
-	mux    *http.ServeMux
-	logger *slog.Logger
-	health *health.Health
+	mux            *http.ServeMux
+	handler        http.Handler
+	middleware     []Middleware
+	allowed        map[string][]string
+	logger         *slog.Logger
+	health         *health.Health
+	maxBodyBytes   int64
+	maxURLLength   int
+	maxQueryLength int
+	trailingSlash  TrailingSlashMode
+	propagate      []string
+	panics         *panicTracker
+	serverTiming   bool
+	ids            id.Generator
+	shedder        *loadShedder
+	limiter        *rateLimiter
+	cache          *responseCache
+	metrics        *httpMetrics
+	cors           *corsPolicy
+	swaggerUI      bool
+	config         *config.Config
+	adminToken     string
+	inFlight       atomic.Int64
+	shuttingDown   atomic.Bool
+}
+
+// Option configures optional Router behavior.
+type Option func(*Router)
+
+// WithMaxBodyBytes sets the maximum accepted request body size in bytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(r *Router) {
+		if n > 0 {
+			r.maxBodyBytes = n
+		}
+	}
+}
+
+// WithTrailingSlash sets how paths ending in a slash are routed.
+func WithTrailingSlash(mode TrailingSlashMode) Option {
+	return func(r *Router) {
+		r.trailingSlash = mode
+	}
+}
+
+// WithPropagateHeaders sets request headers to carry onto Kafka messages
+// produced while handling the request.
+func WithPropagateHeaders(names ...string) Option {
+	return func(r *Router) {
+		r.propagate = names
+	}
+}
+
+// WithPanicThreshold makes liveness fail once threshold handler panics are
+// recovered within window, prompting a restart. A threshold of zero
+// disables the check.
+func WithPanicThreshold(threshold int, window time.Duration) Option {
+	return func(r *Router) {
+		if threshold > 0 && window > 0 {
+			r.panics = newPanicTracker(threshold, window)
+		}
+	}
+}
+
+// WithIDGenerator sets the generator used for request IDs.
+func WithIDGenerator(gen id.Generator) Option {
+	return func(r *Router) {
+		if gen != nil {
+			r.ids = gen
+		}
+	}
+}
+
+// WithLoadShedding rejects percent of new requests with 503 while the p99
+// latency of recent requests exceeds threshold. A zero threshold disables
+// shedding.
+func WithLoadShedding(threshold time.Duration, percent int) Option {
+	return func(r *Router) {
+		if threshold > 0 && percent > 0 {
+			r.shedder = newLoadShedder(threshold, percent)
+		}
+	}
+}
+
+// WithRateLimit limits each client IP to limit. Requests whose header
+// (e.g. X-Tenant-ID) names a tenant in overrides are instead limited per
+// tenant at its own limit; other header values are ignored, so rotating
+// them cannot escape the IP limit. A zero rate disables limiting.
+func WithRateLimit(header string, limit RateLimit, overrides map[string]RateLimit) Option {
+	return func(r *Router) {
+		if limit.Rate > 0 && limit.Burst > 0 {
+			r.limiter = newRateLimiter(header, limit, overrides)
+		}
+	}
+}
+
+// WithResponseCache caches 200 responses to GET requests for paths for
+// ttl. A zero ttl disables caching.
+func WithResponseCache(ttl time.Duration, paths ...string) Option {
+	return func(r *Router) {
+		if ttl > 0 && len(paths) > 0 {
+			r.cache = newResponseCache(ttl, paths)
+		}
+	}
+}
+
+// WithServerTiming enables Server-Timing response headers built from
+// timings recorded with Timing.
+func WithServerTiming(enabled bool) Option {
+	return func(r *Router) {
+		r.serverTiming = enabled
+	}
+}
+
+// WithConfig serves cfg, redacted, as a .env file from
+// GET /api/v1/admin/config.env to callers holding the admin token.
+func WithConfig(cfg *config.Config) Option {
+	return func(r *Router) {
+		r.config = cfg
+	}
 }
 
-func NewRouter(logger *slog.Logger, health *health.Health) *Router {
+func NewRouter(logger *slog.Logger, health *health.Health, opts ...Option) *Router {
 	r := &Router{
-		mux:    http.NewServeMux(),
-		logger: logger,
-		health: health,
+		mux:            http.NewServeMux(),
+		allowed:        make(map[string][]string),
+		logger:         logger,
+		health:         health,
+		maxBodyBytes:   defaultMaxBodyBytes,
+		maxURLLength:   defaultMaxURLLength,
+		maxQueryLength: defaultMaxQueryLength,
+		trailingSlash:  TrailingSlashStrict,
+		ids:            id.UUIDv7(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.panics != nil {
+		r.health.RegisterLiveness("panics", r.panics.check)
 	}
 
 	r.setupRoutes()
+	r.Use(
+		r.logMiddleware,
+		r.recoverMiddleware,
+		r.requestIDMiddleware,
+		r.metricsMiddleware,
+		r.corsMiddleware,
+		r.urlLimitMiddleware,
+		r.negotiateMiddleware,
+		r.rateLimitMiddleware,
+		r.loadSheddingMiddleware,
+		r.serverTimingMiddleware,
+		r.trailingSlashMiddleware,
+		r.propagateHeadersMiddleware,
+		r.bodyLimitMiddleware,
+		r.decompressMiddleware,
+		r.cacheMiddleware,
+	)
 	return r
 }
 
+// Use appends mw to the middleware chain. Middleware runs in the order
+// added, the first being outermost; middleware added after NewRouter runs
+// after the defaults, just before the route handler. Use must not be
+// called while the router is serving requests.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+
+	var h http.Handler = r.mux
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	r.handler = h
+}
+
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.logger.Info("request",
-		"method", req.Method,
-		"path", req.URL.Path,
-		"remote_addr", req.RemoteAddr,
-	)
-	r.mux.ServeHTTP(w, req)
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	r.handler.ServeHTTP(w, req)
+}
+
+// InFlight returns the number of requests currently being handled.
+func (r *Router) InFlight() int64 {
+	return r.inFlight.Load()
+}
+
+// BeginShutdown makes readiness answer 503 from now on so load balancers
+// stop routing new requests while in-flight ones drain. Call it before
+// shutting the server down.
+func (r *Router) BeginShutdown() {
+	r.shuttingDown.Store(true)
+	r.health.MarkShuttingDown()
 }
 
 func (r *Router) setupRoutes() {
-	r.mux.HandleFunc("/health/live", r.livenessHandler)
-	r.mux.HandleFunc("/health/ready", r.readinessHandler)
-	r.mux.HandleFunc("/version", r.versionHandler)
-	r.mux.HandleFunc("/openapi.yaml", r.openapiHandler)
-	r.mux.HandleFunc("/openapi.json", r.openapiHandler) // Keep backward compatibility
-	r.mux.HandleFunc("/api/v1/hello", r.helloHandler)
-	r.mux.HandleFunc("/api/v1/echo", r.echoHandler)
-	r.mux.HandleFunc("/api/v1/admin/log-level", r.logLevelHandler)
+	r.handle("GET /health/live", r.livenessHandler)
+	r.handle("GET /health/ready", r.readinessHandler)
+	r.handle("GET /health/startup", r.startupHandler)
+	r.handle("GET /version", r.versionHandler)
+	r.handle("GET /openapi.yaml", r.openapiHandler)
+	r.handle("GET /openapi.json", r.openapiHandler) // Keep backward compatibility
+	r.handle("GET /api/v1/hello", r.helloHandler)
+	r.handle("POST /api/v1/echo", r.echoHandler)
+	r.handle("GET /api/v1/admin/log-level", r.getLogLevelHandler)
+	r.handle("PUT /api/v1/admin/log-level", r.setLogLevelHandler)
+	r.handle("GET /api/v1/admin/logging", r.requireAdmin(r.getLoggingHandler))
+	r.handle("PUT /api/v1/admin/logging", r.requireAdmin(r.setLoggingHandler))
+	r.handle("GET /api/v1/admin/config", r.configHandler)
+	if r.config != nil {
+		r.handle("GET /api/v1/admin/config.env", r.requireAdmin(r.configEnvHandler))
+	}
+	if r.metrics != nil {
+		r.handle("GET /metrics", r.metricsHandler().ServeHTTP)
+	}
+	if r.swaggerUI {
+		r.handle("GET /docs", r.docsHandler)
+		r.handle("GET /docs/assets/{file}", r.docsAssetsHandler().ServeHTTP)
+	}
+
+	r.mux.HandleFunc("/", r.notFoundHandler)
+}
+
+// handle registers h for a "METHOD /path" pattern. The first pattern for a
+// path also registers a method-less fallback so other methods get a JSON
+// 405 listing the allowed ones instead of the mux's plain-text answer.
+func (r *Router) handle(pattern string, h http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	r.mux.HandleFunc(pattern, h)
+
+	if _, ok := r.allowed[path]; !ok {
+		r.mux.HandleFunc(path, r.methodNotAllowedHandler(path))
+	}
+	r.allowed[path] = append(r.allowed[path], method)
+	if method == http.MethodGet {
+		// GET patterns also match HEAD
+		r.allowed[path] = append(r.allowed[path], http.MethodHead)
+	}
+}
+
+func (r *Router) methodNotAllowedHandler(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Allow", strings.Join(r.allowed[path], ", "))
+		r.respondError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (r *Router) notFoundHandler(w http.ResponseWriter, req *http.Request) {
+	r.respondError(w, http.StatusNotFound, CodeNotFound, "Not found")
 }
 
 func (r *Router) livenessHandler(w http.ResponseWriter, req *http.Request) {
 	check := r.health.Liveness()
-	r.respondJSON(w, http.StatusOK, check)
+
+	status := http.StatusOK
+	if check.Status == health.StatusUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	r.respondJSON(w, status, check)
 }
 
 func (r *Router) readinessHandler(w http.ResponseWriter, req *http.Request) {
-	check := r.health.Readiness(req.Context())
+	// Once shutdown begins readiness fails without pinging dependencies
+	var check health.Check
+	if shallow, _ := strconv.ParseBool(req.URL.Query().Get("shallow")); shallow || r.shuttingDown.Load() {
+		check = r.health.Shallow()
+	} else if group := req.URL.Query().Get("group"); group != "" {
+		// group limits readiness to a subset of checks, e.g. read so a
+		// producer outage does not drain read traffic
+		var err error
+		check, err = r.health.ReadinessGroup(req.Context(), group)
+		if err != nil {
+			r.respondError(w, http.StatusBadRequest, CodeValidationFailed, err.Error())
+			return
+		}
+	} else {
+		check = r.health.Readiness(req.Context())
+	}
 
+	// Degraded means only non-critical checks failed, so it stays 200 and
+	// the instance keeps receiving traffic
 	status := http.StatusOK
-	if check.Status == health.StatusUnhealthy {
+	if check.Status == health.StatusUnhealthy || check.Status == health.StatusMigrating {
 		status = http.StatusServiceUnavailable
 	}
 
+	// verbose=false trims the payload to the status and failing check names
+	if verbose, err := strconv.ParseBool(req.URL.Query().Get("verbose")); err == nil && !verbose {
+		r.respondJSON(w, status, check.Summary())
+		return
+	}
+
 	r.respondJSON(w, status, check)
 }
 
-func (r *Router) helloHandler(w http.ResponseWriter, req *http.Request) {
-	if req.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+func (r *Router) startupHandler(w http.ResponseWriter, req *http.Request) {
+	check := r.health.Startup()
+
+	status := http.StatusOK
+	if check.Status == health.StatusUnhealthy {
+		status = http.StatusServiceUnavailable
 	}
 
+	r.respondJSON(w, status, check)
+}
+
+func (r *Router) helloHandler(w http.ResponseWriter, req *http.Request) {
 	response := map[string]string{
 		"message": "Hello from Go Base Microservice",
 		"version": "1.0.0",
@@ -79,20 +346,68 @@ func (r *Router) helloHandler(w http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) echoHandler(w http.ResponseWriter, req *http.Request) {
-	if req.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	raw, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.respondDecodeError(w, err)
 		return
 	}
 
 	var body map[string]interface{}
-	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
-		r.respondJSON(w, http.StatusBadRequest, map[string]string{
-			"error": "Invalid JSON body",
-		})
+	if err := decodeJSON(bytes.NewReader(raw), &body); err != nil {
+		r.respondError(w, http.StatusBadRequest, CodeInvalidJSON, "Invalid JSON body")
+		return
+	}
+
+	echo := normalizeNumbers(body)
+	if describe, _ := strconv.ParseBool(req.URL.Query().Get("describe")); !describe {
+		r.respondJSON(w, http.StatusOK, echo)
 		return
 	}
 
-	r.respondJSON(w, http.StatusOK, body)
+	r.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"echo": echo,
+		"meta": describePayload(body, len(raw)),
+	})
+}
+
+// payloadMeta describes an echoed payload for debugging clients.
+type payloadMeta struct {
+	Fields int               `json:"fields"`
+	Bytes  int               `json:"bytes"`
+	Types  map[string]string `json:"types"`
+}
+
+func describePayload(body map[string]interface{}, size int) payloadMeta {
+	types := make(map[string]string, len(body))
+	for field, value := range body {
+		types[field] = jsonType(value)
+	}
+
+	return payloadMeta{
+		Fields: len(body),
+		Bytes:  size,
+		Types:  types,
+	}
+}
+
+// jsonType names the JSON type of a value decoded with UseNumber.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
 }
 
 func (r *Router) openapiHandler(w http.ResponseWriter, req *http.Request) {
@@ -121,7 +436,7 @@ func (r *Router) openapiHandler(w http.ResponseWriter, req *http.Request) {
 	// Check if file exists
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		r.logger.Error("OpenAPI spec file not found", "path", filename)
-		http.Error(w, "OpenAPI specification not found", http.StatusNotFound)
+		r.respondError(w, http.StatusNotFound, CodeNotFound, "OpenAPI specification not found")
 		return
 	}
 
@@ -132,53 +447,115 @@ func (r *Router) openapiHandler(w http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) versionHandler(w http.ResponseWriter, req *http.Request) {
-	if req.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	versionInfo := version.Get()
+	r.respondJSON(w, http.StatusOK, versionInfo)
+}
+
+// getLogLevelHandler returns the default level, or the level of the named
+// logger given by ?component=.
+func (r *Router) getLogLevelHandler(w http.ResponseWriter, req *http.Request) {
+	component := req.URL.Query().Get("component")
+	if component == "" {
+		r.respondJSON(w, http.StatusOK, map[string]string{"level": logger.GetLevel()})
 		return
 	}
 
-	versionInfo := version.Get()
-	r.respondJSON(w, http.StatusOK, versionInfo)
+	response := map[string]string{
+		"component": component,
+		"level":     logger.GetLevelFor(component),
+	}
+	r.respondJSON(w, http.StatusOK, response)
 }
 
-func (r *Router) logLevelHandler(w http.ResponseWriter, req *http.Request) {
-	switch req.Method {
-	case http.MethodGet:
-		response := map[string]string{
-			"level": logger.GetLevel(),
-		}
-		r.respondJSON(w, http.StatusOK, response)
+// setLogLevelHandler changes the default level, or only the named logger's
+// when the request has a component.
+func (r *Router) setLogLevelHandler(w http.ResponseWriter, req *http.Request) {
+	var request struct {
+		Component string `json:"component"`
+		Level     string `json:"level"`
+	}
 
-	case http.MethodPut:
-		var request struct {
-			Level string `json:"level"`
-		}
+	if err := decodeJSON(req.Body, &request); err != nil {
+		r.respondDecodeError(w, err)
+		return
+	}
 
-		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
-			r.respondJSON(w, http.StatusBadRequest, map[string]string{
-				"error": "Invalid JSON body",
-			})
-			return
-		}
+	var err error
+	if request.Component == "" {
+		_, err = logger.ChangeLevel(r.logger, request.Level)
+	} else {
+		_, err = logger.ChangeLevelFor(r.logger, request.Component, request.Level)
+	}
+	if err != nil {
+		r.respondError(w, http.StatusBadRequest, CodeValidationFailed, err.Error())
+		return
+	}
 
-		if err := logger.SetLevel(request.Level); err != nil {
-			r.respondJSON(w, http.StatusBadRequest, map[string]string{
-				"error": err.Error(),
-			})
-			return
-		}
+	response := map[string]string{
+		"level":   request.Level,
+		"message": "Log level updated successfully",
+	}
+	if request.Component != "" {
+		response["component"] = request.Component
+	}
+	r.respondJSON(w, http.StatusOK, response)
+}
 
-		r.logger.Info("log level changed", "new_level", request.Level)
+func (r *Router) getLoggingHandler(w http.ResponseWriter, req *http.Request) {
+	r.respondJSON(w, http.StatusOK, logger.GetConfig())
+}
 
-		response := map[string]string{
-			"level":   request.Level,
-			"message": "Log level updated successfully",
-		}
-		r.respondJSON(w, http.StatusOK, response)
+func (r *Router) setLoggingHandler(w http.ResponseWriter, req *http.Request) {
+	var request struct {
+		SampleRate *float64 `json:"sample_rate"`
+	}
 
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if err := decodeJSON(req.Body, &request); err != nil {
+		r.respondDecodeError(w, err)
+		return
+	}
+
+	if request.SampleRate == nil {
+		r.respondError(w, http.StatusBadRequest, CodeValidationFailed, "sample_rate is required")
+		return
+	}
+
+	if err := logger.SetSampleRate(*request.SampleRate); err != nil {
+		r.respondError(w, http.StatusBadRequest, CodeValidationFailed, err.Error())
+		return
+	}
+
+	r.logger.Info("log sample rate changed", "new_sample_rate", *request.SampleRate)
+	r.respondJSON(w, http.StatusOK, logger.GetConfig())
+}
+
+func (r *Router) configHandler(w http.ResponseWriter, req *http.Request) {
+	response := map[string]interface{}{
+		"variables": config.Describe(),
+	}
+	r.respondJSON(w, http.StatusOK, response)
+}
+
+// configEnvHandler exports the configuration the service was loaded with
+// as a .env file so an environment can be reproduced elsewhere.
+func (r *Router) configEnvHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="config.env"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(r.config.EnvFile())); err != nil {
+		r.logger.Error("failed to write config env", "error", err)
+	}
+}
+
+// respondDecodeError answers a failure reading or decoding a JSON request
+// body: 413 when the body exceeded the size limit, 400 otherwise.
+func (r *Router) respondDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		r.respondError(w, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+		return
 	}
+	r.respondError(w, http.StatusBadRequest, CodeInvalidJSON, "Invalid JSON body")
 }
 
 func (r *Router) respondJSON(w http.ResponseWriter, status int, data interface{}) {
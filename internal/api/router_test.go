@@ -10,8 +10,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/sksmith/go-base-ms/internal/config"
 	"github.com/sksmith/go-base-ms/internal/health"
 	internalLogger "github.com/sksmith/go-base-ms/internal/logger"
 )
@@ -200,7 +202,7 @@ func TestRouter_EchoHandler(t *testing.T) {
 			method:         http.MethodGet,
 			body:           "",
 			expectedStatus: http.StatusMethodNotAllowed,
-			expectedBody:   "Method not allowed",
+			expectedBody:   `{"error": "Method not allowed", "code": "METHOD_NOT_ALLOWED"}`,
 		},
 	}
 
@@ -227,22 +229,19 @@ func TestRouter_EchoHandler(t *testing.T) {
 
 			responseBody := strings.TrimSpace(w.Body.String())
 
-			if tt.expectedStatus == http.StatusOK || tt.expectedStatus == http.StatusBadRequest {
-				var expected, actual map[string]interface{}
-				if err := json.Unmarshal([]byte(tt.expectedBody), &expected); err != nil {
-					t.Fatalf("failed to unmarshal expected body: %v", err)
-				}
-				if err := json.Unmarshal([]byte(responseBody), &actual); err != nil {
-					t.Fatalf("failed to unmarshal actual body: %v", err)
-				}
+			// Every response, including errors, is JSON
+			var expected, actual map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.expectedBody), &expected); err != nil {
+				t.Fatalf("failed to unmarshal expected body: %v", err)
+			}
+			if err := json.Unmarshal([]byte(responseBody), &actual); err != nil {
+				t.Fatalf("failed to unmarshal actual body: %v", err)
+			}
 
-				for k, v := range expected {
-					if actual[k] != v {
-						t.Errorf("expected %s=%v, got %v", k, v, actual[k])
-					}
+			for k, v := range expected {
+				if actual[k] != v {
+					t.Errorf("expected %s=%v, got %v", k, v, actual[k])
 				}
-			} else if !strings.Contains(responseBody, tt.expectedBody) {
-				t.Errorf("expected body to contain %q, got %q", tt.expectedBody, responseBody)
 			}
 		})
 	}
@@ -263,13 +262,13 @@ func TestRouter_OpenapiHandler(t *testing.T) {
 			name:           "openapi.json",
 			path:           "/openapi.json",
 			expectedStatus: http.StatusNotFound, // File doesn't exist in test environment
-			contentType:    "text/plain; charset=utf-8",
+			contentType:    "application/json",
 		},
 		{
 			name:           "openapi.yaml",
 			path:           "/openapi.yaml",
 			expectedStatus: http.StatusNotFound, // File doesn't exist in test environment
-			contentType:    "text/plain; charset=utf-8",
+			contentType:    "application/json",
 		},
 	}
 
@@ -478,6 +477,574 @@ func TestRouter_LogLevelHandler(t *testing.T) {
 	}
 }
 
+func TestRouter_LogLevelHandlerComponent(t *testing.T) {
+	const component = "router-test"
+	originalLevel := internalLogger.GetLevel()
+	defer internalLogger.SetLevel(originalLevel)
+	defer internalLogger.SetLevelFor(component, originalLevel)
+
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	router := NewRouter(logger, health.New(&mockChecker{}, &mockChecker{}))
+
+	do := func(method, target, body string) (int, map[string]string) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if method == http.MethodPut {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]string
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return w.Code, response
+	}
+
+	status, response := do(http.MethodPut, "/api/v1/admin/log-level", `{"component": "`+component+`", "level": "debug"}`)
+	if status != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d: %v", status, http.StatusOK, response)
+	}
+	if response["component"] != component || response["level"] != "debug" {
+		t.Errorf("PUT response = %v, want component %s at debug", response, component)
+	}
+
+	// Only the component changed
+	if got := internalLogger.GetLevelFor(component); got != "debug" {
+		t.Errorf("GetLevelFor(%s) = %s, want debug", component, got)
+	}
+	if got := internalLogger.GetLevel(); got != originalLevel {
+		t.Errorf("GetLevel() = %s, want %s unchanged", got, originalLevel)
+	}
+
+	status, response = do(http.MethodGet, "/api/v1/admin/log-level?component="+component, "")
+	if status != http.StatusOK || response["component"] != component || response["level"] != "debug" {
+		t.Errorf("GET ?component= = %d %v, want component %s at debug", status, response, component)
+	}
+
+	status, response = do(http.MethodPut, "/api/v1/admin/log-level", `{"component": "`+component+`", "level": "trace"}`)
+	if status != http.StatusBadRequest || response["code"] != string(CodeValidationFailed) {
+		t.Errorf("PUT invalid level = %d %v, want %d %s", status, response, http.StatusBadRequest, CodeValidationFailed)
+	}
+}
+func TestRouter_LoggingHandler(t *testing.T) {
+	defer internalLogger.SetSampleRate(1)
+
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithAdminToken("admin-secret"))
+
+	t.Run("GET logging config", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/logging", nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response internalLogger.Config
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.Level != internalLogger.GetLevel() {
+			t.Errorf("expected level %s, got %s", internalLogger.GetLevel(), response.Level)
+		}
+		if response.Format == "" {
+			t.Error("expected format in response")
+		}
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedRate   float64
+	}{
+		{
+			name:           "PUT sample rate",
+			body:           `{"sample_rate": 0.5}`,
+			expectedStatus: http.StatusOK,
+			expectedRate:   0.5,
+		},
+		{
+			name:           "PUT out of range sample rate",
+			body:           `{"sample_rate": 2}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedRate:   0.5,
+		},
+		{
+			name:           "PUT missing sample rate",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedRate:   0.5,
+		},
+		{
+			name:           "PUT invalid JSON",
+			body:           `{invalid json}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedRate:   0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/logging", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if got := internalLogger.GetSampleRate(); got != tt.expectedRate {
+				t.Errorf("expected sample rate %v, got %v", tt.expectedRate, got)
+			}
+		})
+	}
+}
+
+func TestRouter_EchoDescribe(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	payload := `{"name":"widget","price":9.99,"tags":["a"],"active":true,"owner":null,"dims":{"w":1}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo?describe=true", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Echo map[string]interface{} `json:"echo"`
+		Meta struct {
+			Fields int               `json:"fields"`
+			Bytes  int               `json:"bytes"`
+			Types  map[string]string `json:"types"`
+		} `json:"meta"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Echo["name"] != "widget" {
+		t.Errorf("expected echo to contain payload, got %v", response.Echo)
+	}
+	if response.Meta.Fields != 6 {
+		t.Errorf("expected 6 fields, got %d", response.Meta.Fields)
+	}
+	if response.Meta.Bytes != len(payload) {
+		t.Errorf("expected %d bytes, got %d", len(payload), response.Meta.Bytes)
+	}
+
+	wantTypes := map[string]string{
+		"name":   "string",
+		"price":  "number",
+		"tags":   "array",
+		"active": "boolean",
+		"owner":  "null",
+		"dims":   "object",
+	}
+	for field, want := range wantTypes {
+		if got := response.Meta.Types[field]; got != want {
+			t.Errorf("expected type of %s to be %s, got %s", field, want, got)
+		}
+	}
+}
+
+func TestRouter_EchoWithoutDescribe(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/echo?describe=false", strings.NewReader(`{"a":1}`))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if got := strings.TrimSpace(w.Body.String()); got != `{"a":1}` {
+		t.Errorf("expected plain echo, got %s", got)
+	}
+}
+
+func TestRouter_InFlight(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	var during int64
+	router.mux.HandleFunc("/test/in-flight", func(w http.ResponseWriter, req *http.Request) {
+		during = router.InFlight()
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/in-flight", nil))
+
+	if during != 1 {
+		t.Errorf("expected 1 in-flight request during handling, got %d", during)
+	}
+	if got := router.InFlight(); got != 0 {
+		t.Errorf("expected 0 in-flight requests after handling, got %d", got)
+	}
+}
+
+func TestRouter_ConfigHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/config", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Variables []config.ConfigVar `json:"variables"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	found := false
+	for _, v := range response.Variables {
+		if v.Name == "PORT" {
+			found = true
+			if v.Default != "8080" {
+				t.Errorf("expected PORT default 8080, got %s", v.Default)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected PORT in config variables")
+	}
+}
+
+func TestRouter_ReadinessDegraded(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	h.Register("cache", &mockChecker{shouldFail: true}, health.NonCritical())
+	router := NewRouter(logger, h)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response health.Check
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Status != health.StatusDegraded {
+		t.Errorf("expected health status %s, got %s", health.StatusDegraded, response.Status)
+	}
+}
+
+func TestRouter_ReadinessGroup(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{shouldFail: true})
+	h.SetGroup(health.GroupRead, "database")
+	h.SetGroup(health.GroupWrite, "database", "kafka")
+	router := NewRouter(logger, h)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "read group stays ready during kafka outage", path: "/health/ready?group=read", wantStatus: http.StatusOK},
+		{name: "write group fails during kafka outage", path: "/health/ready?group=write", wantStatus: http.StatusServiceUnavailable},
+		{name: "no group checks everything", path: "/health/ready", wantStatus: http.StatusServiceUnavailable},
+		{name: "unknown group", path: "/health/ready?group=admin", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_StartupHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	probe := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/startup", nil))
+		return w.Code
+	}
+
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Errorf("before start: expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	h.MarkStarted()
+
+	if code := probe(); code != http.StatusOK {
+		t.Errorf("after start: expected status %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestRouter_ReadinessVerbose(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantDetails bool
+	}{
+		{name: "default is verbose", query: "", wantDetails: true},
+		{name: "verbose", query: "?verbose=true", wantDetails: true},
+		{name: "compact", query: "?verbose=false", wantDetails: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{shouldFail: true})
+			h.Register("cache", &mockChecker{shouldFail: true}, health.NonCritical())
+			router := NewRouter(logger, h)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready"+tt.query, nil))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+			}
+
+			var response map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response["status"] != string(health.StatusUnhealthy) {
+				t.Errorf("expected status %s, got %v", health.StatusUnhealthy, response["status"])
+			}
+
+			_, hasDetails := response["details"]
+			if hasDetails != tt.wantDetails {
+				t.Errorf("details present = %v, want %v", hasDetails, tt.wantDetails)
+			}
+			if tt.wantDetails {
+				return
+			}
+
+			failing, _ := response["failing"].([]interface{})
+			if len(failing) != 2 || failing[0] != "cache" || failing[1] != "kafka" {
+				t.Errorf("expected failing [cache kafka], got %v", response["failing"])
+			}
+		})
+	}
+}
+
+func TestRouter_ReadinessShallow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	// Failing dependencies would make deep readiness return 503
+	h := health.New(&mockChecker{shouldFail: true}, &mockChecker{shouldFail: true})
+	router := NewRouter(logger, h)
+
+	req := httptest.NewRequest(http.MethodGet, "/health/ready?shallow=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected shallow readiness status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected deep readiness status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	h.MarkShuttingDown()
+
+	req = httptest.NewRequest(http.MethodGet, "/health/ready?shallow=true", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected shallow readiness status %d during shutdown, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestRouter_BeginShutdown(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	for _, target := range []string{"/health/ready", "/health/ready?shallow=true"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s before shutdown = %d, want %d", target, w.Code, http.StatusOK)
+		}
+	}
+
+	router.BeginShutdown()
+
+	for _, target := range []string{"/health/ready", "/health/ready?shallow=true"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("GET %s after BeginShutdown = %d, want %d", target, w.Code, http.StatusServiceUnavailable)
+		}
+
+		var check health.Check
+		if err := json.NewDecoder(w.Body).Decode(&check); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if check.Details["shutting_down"] != true {
+			t.Errorf("GET %s details = %v, want shutting_down", target, check.Details)
+		}
+	}
+
+	// Liveness keeps passing so the process is not restarted mid-drain
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("liveness after BeginShutdown = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRouter_ConfigEnvHandler(t *testing.T) {
+	t.Setenv("KAFKA_SASL_PASSWORD", "s3cret")
+	t.Setenv("DATABASE_URL", "postgres://app@db.example.com/orders")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithConfig(cfg), WithAdminToken("admin-secret"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/config.env", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain content type, got %s", ct)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "PORT=8080\n") {
+		t.Errorf("expected PORT=8080 line, got:\n%s", body)
+	}
+	if !strings.Contains(body, "DB_HOST=db.example.com\n") {
+		t.Errorf("expected DB_HOST from DATABASE_URL, got:\n%s", body)
+	}
+	if !strings.Contains(body, "KAFKA_SASL_PASSWORD=REDACTED\n") {
+		t.Errorf("expected redacted KAFKA_SASL_PASSWORD line, got:\n%s", body)
+	}
+	if strings.Contains(body, "s3cret") {
+		t.Error("expected secret value to be redacted")
+	}
+}
+
+func TestRouter_AccessLogTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     bool
+		wantTLS bool
+	}{
+		{
+			name:    "plain HTTP",
+			tls:     false,
+			wantTLS: false,
+		},
+		{
+			name:    "TLS",
+			tls:     true,
+			wantTLS: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &syncBuffer{}
+			logger := slog.New(slog.NewJSONHandler(buf, nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h)
+
+			var srv *httptest.Server
+			if tt.tls {
+				srv = httptest.NewTLSServer(router)
+			} else {
+				srv = httptest.NewServer(router)
+			}
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL + "/health/live")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to decode access log: %v", err)
+			}
+
+			if entry["proto"] != "HTTP/1.1" {
+				t.Errorf("expected proto HTTP/1.1, got %v", entry["proto"])
+			}
+
+			_, hasVersion := entry["tls_version"]
+			_, hasCipher := entry["tls_cipher"]
+			if hasVersion != tt.wantTLS || hasCipher != tt.wantTLS {
+				t.Errorf("expected TLS fields present=%v, got tls_version=%v tls_cipher=%v",
+					tt.wantTLS, entry["tls_version"], entry["tls_cipher"])
+			}
+			if tt.wantTLS && !strings.HasPrefix(entry["tls_version"].(string), "TLS ") {
+				t.Errorf("unexpected tls_version %v", entry["tls_version"])
+			}
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for use by a server goroutine and the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
 // Helper functions for OpenAPI testing
 func generateTestOpenAPIFiles(t *testing.T) error {
 	// Create a minimal test OpenAPI spec
@@ -508,3 +1075,47 @@ func cleanupTestOpenAPIFiles(t *testing.T) {
 	os.Remove("api/openapi.yaml")
 	os.Remove("api")
 }
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h)
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantCode   ErrorCode
+		wantAllow  string
+	}{
+		{http.MethodPost, "/api/v1/hello", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET, HEAD"},
+		{http.MethodGet, "/api/v1/echo", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "POST"},
+		{http.MethodDelete, "/api/v1/admin/log-level", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET, HEAD, PUT"},
+		{http.MethodGet, "/api/v1/missing", http.StatusNotFound, CodeNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sheddingSamples is how many recent request latencies feed the p99.
+	sheddingSamples = 200
+	// sheddingMinSamples avoids shedding on a handful of slow requests.
+	sheddingMinSamples = 20
+	// sheddingMaxAge expires samples so shedding stops even when every
+	// request is being rejected and no new latencies are recorded.
+	sheddingMaxAge = 10 * time.Second
+)
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// loadShedder rejects a percentage of new requests while the p99 latency of
+// recent requests is above threshold.
+type loadShedder struct {
+	threshold time.Duration
+	percent   int
+	random    func() float64
+	now       func() time.Time
+
+	mu      sync.Mutex
+	samples []latencySample
+	next    int
+}
+
+func newLoadShedder(threshold time.Duration, percent int) *loadShedder {
+	return &loadShedder{
+		threshold: threshold,
+		percent:   percent,
+		random:    rand.Float64,
+		now:       time.Now,
+		samples:   make([]latencySample, 0, sheddingSamples),
+	}
+}
+
+func (s *loadShedder) record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sample := latencySample{at: s.now(), duration: d}
+	if len(s.samples) < sheddingSamples {
+		s.samples = append(s.samples, sample)
+		return
+	}
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % sheddingSamples
+}
+
+// recent returns the latencies recorded within sheddingMaxAge, sorted.
+func (s *loadShedder) recent() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-sheddingMaxAge)
+	recent := make([]time.Duration, 0, len(s.samples))
+	for _, sample := range s.samples {
+		if sample.at.After(cutoff) {
+			recent = append(recent, sample.duration)
+		}
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	return recent
+}
+
+func (s *loadShedder) shouldShed() bool {
+	recent := s.recent()
+	if len(recent) < sheddingMinSamples {
+		return false
+	}
+
+	p99 := recent[(len(recent)*99)/100]
+	if p99 <= s.threshold {
+		return false
+	}
+	return s.random()*100 < float64(s.percent)
+}
+
+// loadSheddingMiddleware returns 503 for a share of requests while latency
+// is above the configured threshold. Health endpoints are never shed so
+// probes keep reporting accurately during overload.
+func (r *Router) loadSheddingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.shedder == nil || strings.HasPrefix(req.URL.Path, "/health/") {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if r.shedder.shouldShed() {
+			w.Header().Set("Retry-After", "1")
+			r.respondError(w, http.StatusServiceUnavailable, CodeOverloaded, "Service overloaded")
+			return
+		}
+
+		start := time.Now()
+		next.ServeHTTP(w, req)
+		r.shedder.record(time.Since(start))
+	})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestLoadSheddingMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithLoadShedding(5*time.Millisecond, 100))
+
+	delay := 10 * time.Millisecond
+	handler := router.loadSheddingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Slow requests are served until enough samples are collected
+	for i := 0; i < sheddingMinSamples; i++ {
+		if w := serve("/api/v1/hello"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status 200 while warming up, got %d", i, w.Code)
+		}
+	}
+
+	w := serve("/api/v1/hello")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 once p99 exceeds threshold, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on shed response")
+	}
+
+	delay = 0
+	if w := serve("/health/ready"); w.Code != http.StatusOK {
+		t.Errorf("expected health endpoint not to be shed, got %d", w.Code)
+	}
+}
+
+func TestLoadShedder_ShouldShed(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency time.Duration
+		samples int
+		random  float64
+		want    bool
+	}{
+		{name: "below threshold", latency: time.Millisecond, samples: 50, random: 0, want: false},
+		{name: "too few samples", latency: time.Second, samples: 5, random: 0, want: false},
+		{name: "shed within percent", latency: time.Second, samples: 50, random: 0.2, want: true},
+		{name: "admit beyond percent", latency: time.Second, samples: 50, random: 0.8, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLoadShedder(100*time.Millisecond, 50)
+			s.random = func() float64 { return tt.random }
+			for i := 0; i < tt.samples; i++ {
+				s.record(tt.latency)
+			}
+
+			if got := s.shouldShed(); got != tt.want {
+				t.Errorf("shouldShed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadShedder_WindowRecovers(t *testing.T) {
+	s := newLoadShedder(100*time.Millisecond, 100)
+	for i := 0; i < sheddingSamples; i++ {
+		s.record(time.Second)
+	}
+	if !s.shouldShed() {
+		t.Fatal("expected shedding with slow samples")
+	}
+
+	// Fast requests push slow samples out of the window
+	for i := 0; i < sheddingSamples; i++ {
+		s.record(time.Millisecond)
+	}
+	if s.shouldShed() {
+		t.Error("expected shedding to stop once latency recovers")
+	}
+}
+
+func TestLoadShedder_SamplesExpire(t *testing.T) {
+	now := time.Now()
+	s := newLoadShedder(100*time.Millisecond, 100)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < sheddingSamples; i++ {
+		s.record(time.Second)
+	}
+	if !s.shouldShed() {
+		t.Fatal("expected shedding with slow samples")
+	}
+
+	// Even with every request shed, old samples age out
+	now = now.Add(sheddingMaxAge + time.Second)
+	if s.shouldShed() {
+		t.Error("expected shedding to stop once samples expire")
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestRouter_SwaggerUI(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		wantStatus int
+	}{
+		{name: "enabled", enabled: true, wantStatus: http.StatusOK},
+		{name: "disabled", enabled: false, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithSwaggerUI(tt.enabled))
+
+			req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+			req.Header.Set("Accept", "text/html")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !tt.enabled {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+				t.Errorf("Content-Type = %q, want text/html", ct)
+			}
+			if !strings.Contains(w.Body.String(), `"/openapi.json"`) {
+				t.Errorf("expected page to load /openapi.json, got %q", w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "https://") {
+				t.Errorf("expected page to load only embedded assets, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_SwaggerUIAssets(t *testing.T) {
+	tests := []struct {
+		path            string
+		wantStatus      int
+		wantContentType string
+	}{
+		{path: "/docs/assets/swagger-ui.css", wantStatus: http.StatusOK, wantContentType: "text/css"},
+		{path: "/docs/assets/swagger-ui-bundle.js", wantStatus: http.StatusOK, wantContentType: "text/javascript"},
+		{path: "/docs/assets/missing.js", wantStatus: http.StatusNotFound, wantContentType: "application/json"},
+		{path: "/docs/assets/", wantStatus: http.StatusNotFound},
+	}
+
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	h := health.New(&mockChecker{}, &mockChecker{})
+	router := NewRouter(logger, h, WithSwaggerUI(true))
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", "*/*")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); tt.wantContentType != "" && !strings.HasPrefix(ct, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %s", ct, tt.wantContentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timingEntry is a single named duration reported in Server-Timing.
+type timingEntry struct {
+	name     string
+	duration time.Duration
+}
+
+// serverTimings collects the timings recorded while handling one request.
+type serverTimings struct {
+	mu      sync.Mutex
+	entries []timingEntry
+}
+
+func (s *serverTimings) add(name string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, timingEntry{name: name, duration: d})
+}
+
+// header formats the recorded timings plus total as a Server-Timing value,
+// e.g. "db;dur=12.50, total;dur=20.10".
+func (s *serverTimings) header(total time.Duration) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := make([]string, 0, len(s.entries)+1)
+	for _, e := range s.entries {
+		parts = append(parts, formatTiming(e.name, e.duration))
+	}
+	parts = append(parts, formatTiming("total", total))
+	return strings.Join(parts, ", ")
+}
+
+func formatTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.2f", name, float64(d)/float64(time.Millisecond))
+}
+
+// Timing starts a named timing for the request in ctx and returns the
+// function that stops it:
+//
+//	defer api.Timing(ctx, "db")()
+//
+// It is a no-op when Server-Timing is disabled.
+func Timing(ctx context.Context, name string) func() {
+	timings := RequestContextFrom(ctx).timingCollector()
+	if timings == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		timings.add(name, time.Since(start))
+	}
+}
+
+// timingWriter sets the Server-Timing header just before the response
+// headers are written.
+type timingWriter struct {
+	http.ResponseWriter
+	timings     *serverTimings
+	start       time.Time
+	wroteHeader bool
+}
+
+func (w *timingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("Server-Timing", w.timings.header(time.Since(w.start)))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// serverTimingMiddleware exposes timings recorded with Timing as a
+// Server-Timing response header when enabled.
+func (r *Router) serverTimingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.serverTiming {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		timings := &serverTimings{}
+		tw := &timingWriter{ResponseWriter: w, timings: timings, start: time.Now()}
+		req, rc := withRequestContext(req)
+		rc.setTimingCollector(timings)
+		next.ServeHTTP(tw, req)
+	})
+}
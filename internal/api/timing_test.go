@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestServerTimingMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		pattern string
+	}{
+		{
+			name:    "enabled",
+			enabled: true,
+			pattern: `^db;dur=\d+\.\d{2}, total;dur=\d+\.\d{2}$`,
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+			pattern: `^$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithServerTiming(tt.enabled))
+
+			handler := router.serverTimingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				stop := Timing(req.Context(), "db")
+				time.Sleep(time.Millisecond)
+				stop()
+				w.Write([]byte("ok"))
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil))
+
+			got := w.Header().Get("Server-Timing")
+			if !regexp.MustCompile(tt.pattern).MatchString(got) {
+				t.Errorf("Server-Timing = %q, want match for %s", got, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestServerTimings_Header(t *testing.T) {
+	timings := &serverTimings{}
+	timings.add("db", 12500*time.Microsecond)
+	timings.add("kafka", 4*time.Millisecond)
+
+	want := "db;dur=12.50, kafka;dur=4.00, total;dur=20.10"
+	if got := timings.header(20100 * time.Microsecond); got != want {
+		t.Errorf("header() = %q, want %q", got, want)
+	}
+}
+
+func TestTiming_NoRecorder(t *testing.T) {
+	// Must not panic when Server-Timing is disabled, inside or outside a
+	// request
+	Timing(context.Background(), "db")()
+
+	req, _ := withRequestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	Timing(req.Context(), "db")()
+}
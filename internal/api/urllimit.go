@@ -0,0 +1,45 @@
+package api
+
+import "net/http"
+
+// Default URL limits, generous enough for any legitimate request.
+const (
+	defaultMaxURLLength   = 8192
+	defaultMaxQueryLength = 4096
+)
+
+// WithURLLimits sets the longest request URI and query string accepted
+// before answering 414. Non-positive values keep the defaults.
+func WithURLLimits(maxURL, maxQuery int) Option {
+	return func(r *Router) {
+		if maxURL > 0 {
+			r.maxURLLength = maxURL
+		}
+		if maxQuery > 0 {
+			r.maxQueryLength = maxQuery
+		}
+	}
+}
+
+// urlLimitMiddleware rejects requests whose URI or query string exceeds
+// the configured limits.
+func (r *Router) urlLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		uri := req.RequestURI
+		if uri == "" {
+			uri = req.URL.RequestURI()
+		}
+
+		if len(uri) > r.maxURLLength || len(req.URL.RawQuery) > r.maxQueryLength {
+			r.respondJSON(w, http.StatusRequestURITooLong, map[string]interface{}{
+				"error":            "URI too long",
+				"code":             CodeURITooLong,
+				"max_url_length":   r.maxURLLength,
+				"max_query_length": r.maxQueryLength,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+func TestURLLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{
+			name:       "normal url",
+			target:     "/api/v1/hello?name=world",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "url over limit",
+			target:     "/api/v1/" + strings.Repeat("a", 100),
+			wantStatus: http.StatusRequestURITooLong,
+		},
+		{
+			name:       "query over limit",
+			target:     "/api/v1/hello?q=" + strings.Repeat("a", 40),
+			wantStatus: http.StatusRequestURITooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+			h := health.New(&mockChecker{}, &mockChecker{})
+			router := NewRouter(logger, h, WithURLLimits(64, 32))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusRequestURITooLong {
+				return
+			}
+
+			var body map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body["error"] != "URI too long" {
+				t.Errorf("error = %v, want URI too long", body["error"])
+			}
+		})
+	}
+}
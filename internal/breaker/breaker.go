@@ -0,0 +1,149 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Do when the breaker is rejecting calls.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// errPanicked is recorded as the outcome of a call that panicked.
+var errPanicked = errors.New("call panicked")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker protects calls to a downstream dependency. After threshold
+// consecutive failures it opens and rejects calls with ErrOpen. Once
+// resetTimeout has elapsed a single trial call is let through (half-open);
+// success closes the breaker, failure opens it again.
+type Breaker struct {
+	threshold    int
+	resetTimeout time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func New(threshold int, resetTimeout time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+		now:          time.Now,
+	}
+}
+
+// Do runs fn if the breaker allows it and records the outcome. A panic in
+// fn is recorded as a failure before it propagates, so it cannot leave a
+// half-open trial outstanding.
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			b.record(errPanicked)
+		}
+	}()
+
+	err := fn()
+	completed = true
+	b.record(err)
+	return err
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.resetTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.resetTimeout {
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.trial = true
+		return nil
+	case StateHalfOpen:
+		// Only one trial call at a time while half-open
+		if b.trial {
+			return ErrOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.trial = false
+		if err != nil {
+			b.trip()
+			return
+		}
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.trip()
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
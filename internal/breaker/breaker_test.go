@@ -0,0 +1,217 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errDownstream = errors.New("downstream failed")
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestBreaker(threshold int, resetTimeout time.Duration) (*Breaker, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := New(threshold, resetTimeout)
+	b.now = clock.Now
+	return b, clock
+}
+
+func fail() error    { return errDownstream }
+func succeed() error { return nil }
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Second)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Do(fail); !errors.Is(err, errDownstream) {
+			t.Fatalf("Do() error = %v, want %v", err, errDownstream)
+		}
+		if b.State() != StateClosed {
+			t.Fatalf("State() = %v after %d failures, want closed", b.State(), i+1)
+		}
+	}
+
+	b.Do(fail)
+	if b.State() != StateOpen {
+		t.Fatalf("State() = %v, want open", b.State())
+	}
+
+	called := false
+	err := b.Do(func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrOpen) {
+		t.Errorf("Do() error = %v, want ErrOpen", err)
+	}
+	if called {
+		t.Error("Do() should not call fn while open")
+	}
+}
+
+func TestBreaker_SuccessResetsFailureCount(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Second)
+
+	b.Do(fail)
+	b.Do(succeed)
+	b.Do(fail)
+
+	if b.State() != StateClosed {
+		t.Errorf("State() = %v, want closed", b.State())
+	}
+}
+
+func TestBreaker_HalfOpenTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		trial     func() error
+		wantState State
+	}{
+		{
+			name:      "trial success closes",
+			trial:     succeed,
+			wantState: StateClosed,
+		},
+		{
+			name:      "trial failure reopens",
+			trial:     fail,
+			wantState: StateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, clock := newTestBreaker(1, time.Second)
+
+			b.Do(fail)
+			if b.State() != StateOpen {
+				t.Fatalf("State() = %v, want open", b.State())
+			}
+
+			clock.Advance(time.Second)
+			if b.State() != StateHalfOpen {
+				t.Fatalf("State() = %v, want half-open", b.State())
+			}
+
+			b.Do(tt.trial)
+			if b.State() != tt.wantState {
+				t.Errorf("State() = %v, want %v", b.State(), tt.wantState)
+			}
+		})
+	}
+}
+
+func TestBreaker_HalfOpenAllowsSingleTrial(t *testing.T) {
+	b, clock := newTestBreaker(1, time.Second)
+
+	b.Do(fail)
+	clock.Advance(time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go b.Do(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	if err := b.Do(succeed); !errors.Is(err, ErrOpen) {
+		t.Errorf("Do() during trial error = %v, want ErrOpen", err)
+	}
+
+	close(release)
+}
+
+func TestBreaker_HalfOpenTrialPanics(t *testing.T) {
+	b, clock := newTestBreaker(1, time.Second)
+
+	b.Do(fail)
+	clock.Advance(time.Second)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recover() = %v, want the panic to propagate", r)
+			}
+		}()
+		b.Do(func() error { panic("boom") })
+	}()
+
+	if b.State() != StateOpen {
+		t.Fatalf("State() = %v, want open after the trial panicked", b.State())
+	}
+
+	// The failed trial must not block the next one
+	clock.Advance(time.Second)
+	if err := b.Do(succeed); err != nil {
+		t.Errorf("Do() after reset timeout error = %v, want nil", err)
+	}
+	if b.State() != StateClosed {
+		t.Errorf("State() = %v, want closed", b.State())
+	}
+}
+
+func TestBreaker_Concurrent(t *testing.T) {
+	b := New(5, 10*time.Millisecond)
+
+	var calls atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Do(func() error {
+					calls.Add(1)
+					if (i+j)%3 == 0 {
+						return errDownstream
+					}
+					return nil
+				})
+				b.State()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if calls.Load() == 0 {
+		t.Error("expected some calls to pass through the breaker")
+	}
+}
+
+func TestState_String(t *testing.T) {
+	tests := []struct {
+		state State
+		want  string
+	}{
+		{StateClosed, "closed"},
+		{StateOpen, "open"},
+		{StateHalfOpen, "half-open"},
+		{State(99), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("State(%d).String() = %v, want %v", tt.state, got, tt.want)
+		}
+	}
+}
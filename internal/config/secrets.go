@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// secretsTimeout bounds fetching every referenced secret during Load.
+const secretsTimeout = 10 * time.Second
+
+// SecretRef is a reference to a secret in an external store, written as
+// scheme://path#key, e.g. vault://secret/data/orders#db_password or
+// awssm://orders/prod#db_password.
+type SecretRef struct {
+	Scheme string
+	Path   string
+	Key    string
+}
+
+// SecretsProvider fetches referenced secrets from an external store.
+type SecretsProvider interface {
+	Secret(ctx context.Context, ref SecretRef) (string, error)
+}
+
+// LoadOption configures optional Load behavior.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	secrets map[string]SecretsProvider
+}
+
+// WithSecretsProvider resolves values referencing scheme (e.g. "vault" or
+// "awssm") through provider. Without any provider, values are used as is.
+func WithSecretsProvider(scheme string, provider SecretsProvider) LoadOption {
+	return func(o *loadOptions) {
+		if o.secrets == nil {
+			o.secrets = make(map[string]SecretsProvider)
+		}
+		o.secrets[scheme] = provider
+	}
+}
+
+// parseSecretRef parses value as a reference to one of schemes.
+func parseSecretRef(value string, schemes map[string]SecretsProvider) (SecretRef, bool) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok {
+		return SecretRef{}, false
+	}
+	if _, known := schemes[scheme]; !known {
+		return SecretRef{}, false
+	}
+
+	path, key, _ := strings.Cut(rest, "#")
+	return SecretRef{Scheme: scheme, Path: path, Key: key}, true
+}
+
+// resolveSecrets replaces every value in env that references a configured
+// provider with the fetched secret. Other values pass through unchanged.
+func resolveSecrets(ctx context.Context, env map[string]string, providers map[string]SecretsProvider) error {
+	if len(providers) == 0 {
+		return nil
+	}
+
+	for name, value := range env {
+		ref, ok := parseSecretRef(value, providers)
+		if !ok {
+			continue
+		}
+		if ref.Path == "" || ref.Key == "" {
+			return fmt.Errorf("invalid %s: secret reference must be %s://path#key", name, ref.Scheme)
+		}
+
+		secret, err := providers[ref.Scheme].Secret(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("invalid %s: failed to resolve secret: %w", name, err)
+		}
+		env[name] = secret
+	}
+	return nil
+}
+
+// VaultProvider reads secrets from HashiCorp Vault's HTTP API, supporting
+// both KV version 1 and version 2 mounts. For KV v2 the path includes the
+// data segment, e.g. vault://secret/data/orders#db_password.
+type VaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+func NewVaultProvider(addr, token string) *VaultProvider {
+	return &VaultProvider{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: secretsTimeout},
+	}
+}
+
+func (p *VaultProvider) Secret(ctx context.Context, ref SecretRef) (string, error) {
+	endpoint := p.addr + "/v1/" + (&url.URL{Path: strings.TrimPrefix(ref.Path, "/")}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: reading %s returned %s", ref.Path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault: decoding %s: %w", ref.Path, err)
+	}
+
+	// KV v2 nests the secret's fields under data.data
+	fields := body.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		fields = nested
+	}
+
+	value, ok := fields[ref.Key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: key %q not found in %s", ref.Key, ref.Path)
+	}
+	return value, nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault serves a few KV v1 and v2 secrets the way Vault's HTTP API
+// does and records the paths it was asked for.
+type fakeVault struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.paths = append(f.paths, r.URL.EscapedPath())
+	f.mu.Unlock()
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != "s.token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/secret/data/orders":
+		fmt.Fprint(w, `{"data":{"data":{"db_password":"kv2"},"metadata":{"version":3}}}`)
+	case "/v1/kv/orders":
+		fmt.Fprint(w, `{"data":{"db_password":"kv1"}}`)
+	case "/v1/kv/my app":
+		fmt.Fprint(w, `{"data":{"db_password":"spaced"}}`)
+	case "/v1/kv/broken":
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeVault) lastPath() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.paths) == 0 {
+		return ""
+	}
+	return f.paths[len(f.paths)-1]
+}
+
+func TestVaultProvider_Secret(t *testing.T) {
+	vault := &fakeVault{}
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		path     string
+		key      string
+		wantPath string
+		want     string
+		wantErr  string
+	}{
+		{name: "kv v2 unwraps data.data", path: "secret/data/orders", key: "db_password", wantPath: "/v1/secret/data/orders", want: "kv2"},
+		{name: "kv v1", path: "kv/orders", key: "db_password", wantPath: "/v1/kv/orders", want: "kv1"},
+		{name: "leading slash", path: "/kv/orders", key: "db_password", wantPath: "/v1/kv/orders", want: "kv1"},
+		{name: "escaped path", path: "kv/my app", key: "db_password", wantPath: "/v1/kv/my%20app", want: "spaced"},
+		{name: "missing key", path: "kv/orders", key: "missing", wantPath: "/v1/kv/orders", wantErr: `key "missing" not found`},
+		{name: "kv v2 metadata is not a field", path: "secret/data/orders", key: "metadata", wantPath: "/v1/secret/data/orders", wantErr: "not found"},
+		{name: "not found", path: "kv/absent", key: "db_password", wantPath: "/v1/kv/absent", wantErr: "404 Not Found"},
+		{name: "server error", path: "kv/broken", key: "db_password", wantPath: "/v1/kv/broken", wantErr: "500 Internal Server Error"},
+		{name: "wrong token", token: "s.wrong", path: "kv/orders", key: "db_password", wantPath: "/v1/kv/orders", wantErr: "403 Forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token
+			if token == "" {
+				token = "s.token"
+			}
+			provider := NewVaultProvider(srv.URL+"/", token)
+
+			got, err := provider.Secret(context.Background(), SecretRef{Scheme: "vault", Path: tt.path, Key: tt.key})
+			if path := vault.lastPath(); path != tt.wantPath {
+				t.Errorf("requested %q, want %q", path, tt.wantPath)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Secret() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Secret() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Secret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_VaultProvider(t *testing.T) {
+	srv := httptest.NewServer(&fakeVault{})
+	defer srv.Close()
+
+	t.Setenv("DB_PASSWORD", "vault://secret/data/orders#db_password")
+
+	got, err := Load(WithSecretsProvider("vault", NewVaultProvider(srv.URL, "s.token")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.Database.Password != "kv2" {
+		t.Errorf("Database.Password = %q, want kv2", got.Database.Password)
+	}
+
+	t.Setenv("DB_PASSWORD", "vault://kv/broken#db_password")
+	if _, err := Load(WithSecretsProvider("vault", NewVaultProvider(srv.URL, "s.token"))); err == nil || !strings.Contains(err.Error(), "DB_PASSWORD") {
+		t.Errorf("Load() error = %v, want DB_PASSWORD error", err)
+	}
+}
@@ -4,56 +4,153 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/retry"
 )
 
+// connectPolicy retries the initial ping so a database that is still
+// starting up does not fail the service.
+var connectPolicy = retry.Policy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    time.Second,
+	Jitter:      0.2,
+}
+
 type DB struct {
 	conn *sql.DB
+	// queryLogger logs executed statements when query logging is enabled.
+	queryLogger *slog.Logger
 }
 
-func New(ctx context.Context, cfg config.DatabaseConfig) (*DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+// Option configures optional DB behaviour.
+type Option func(*options)
+
+type options struct {
+	logger *slog.Logger
+}
+
+// WithLogger sets the logger used for query logging. Defaults to
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
 
-	conn, err := sql.Open("postgres", dsn)
+func New(ctx context.Context, cfg config.DatabaseConfig, opts ...Option) (*DB, error) {
+	o := options{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	conn, err := sql.Open("postgres", buildDSN(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	conn.SetMaxOpenConns(cfg.MaxOpenConns)
 	conn.SetMaxIdleConns(cfg.MaxIdleConns)
-	conn.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Minute)
+	conn.SetConnMaxLifetime(connMaxLifetime(cfg, rand.Float64))
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if err := conn.PingContext(ctx); err != nil {
+	err = retry.Do(ctx, connectPolicy, func() error {
+		return conn.PingContext(ctx)
+	})
+	if err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{conn: conn}, nil
+	db := &DB{conn: conn}
+	if cfg.LogQueries {
+		db.queryLogger = o.logger
+	}
+	return db, nil
+}
+
+// connMaxLifetime applies the configured jitter to ConnMaxLifetime. random
+// returns a value in [0, 1).
+func connMaxLifetime(cfg config.DatabaseConfig, random func() float64) time.Duration {
+	lifetime := time.Duration(cfg.ConnMaxLifetime) * time.Minute
+	if cfg.ConnLifetimeJitter <= 0 || lifetime <= 0 {
+		return lifetime
+	}
+
+	// Scale into [-jitter, +jitter] percent
+	factor := (random()*2 - 1) * float64(cfg.ConnLifetimeJitter) / 100
+	return lifetime + time.Duration(float64(lifetime)*factor)
+}
+
+// buildDSN builds a libpq keyword/value connection string from cfg.
+func buildDSN(cfg config.DatabaseConfig) string {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		dsnValue(cfg.Host), cfg.Port, dsnValue(cfg.User), dsnValue(cfg.Password),
+		dsnValue(cfg.DBName), dsnValue(cfg.SSLMode))
+
+	if cfg.AppName != "" {
+		dsn += fmt.Sprintf(" application_name=%s", dsnValue(cfg.AppName))
+	}
+
+	if cfg.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout.Milliseconds())
+	}
+
+	return dsn
+}
+
+// dsnValue quotes v per libpq rules when it is empty or contains
+// whitespace, quotes, backslashes or equals signs, backslash-escaping
+// embedded quotes and backslashes.
+func dsnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n\r'\\=") {
+		return v
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v)
+	return "'" + escaped + "'"
 }
 
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Stats returns connection pool statistics.
+func (db *DB) Stats() sql.DBStats {
+	return db.conn.Stats()
+}
+
 func (db *DB) Ping(ctx context.Context) error {
 	return db.conn.PingContext(ctx)
 }
 
 func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return db.conn.ExecContext(ctx, query, args...)
+	start := time.Now()
+	result, err := db.conn.ExecContext(ctx, query, args...)
+	db.logQuery(ctx, "exec", query, args, start, err)
+	return result, err
 }
 
 func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return db.conn.QueryContext(ctx, query, args...)
+	start := time.Now()
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	db.logQuery(ctx, "query", query, args, start, err)
+	return rows, err
 }
 
 func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return db.conn.QueryRowContext(ctx, query, args...)
+	start := time.Now()
+	row := db.conn.QueryRowContext(ctx, query, args...)
+	db.logQuery(ctx, "query_row", query, args, start, row.Err())
+	return row
 }
@@ -2,8 +2,9 @@ package db
 
 import (
 	"context"
-	"fmt"
+	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/sksmith/go-base-ms/internal/config"
 )
@@ -62,18 +63,111 @@ func TestDB_ConnectionString(t *testing.T) {
 			},
 			want: "host=db.example.com port=5433 user=admin password=password123 dbname=production sslmode=require",
 		},
+		{
+			name: "with application name",
+			cfg: config.DatabaseConfig{
+				Host:     "localhost",
+				Port:     5432,
+				User:     "postgres",
+				Password: "secret",
+				DBName:   "testdb",
+				SSLMode:  "disable",
+				AppName:  "go-base-ms-pod-1",
+			},
+			want: "host=localhost port=5432 user=postgres password=secret dbname=testdb sslmode=disable application_name=go-base-ms-pod-1",
+		},
+		{
+			name: "with statement timeout",
+			cfg: config.DatabaseConfig{
+				Host:             "localhost",
+				Port:             5432,
+				User:             "postgres",
+				Password:         "secret",
+				DBName:           "testdb",
+				SSLMode:          "disable",
+				StatementTimeout: 2 * time.Second,
+			},
+			want: "host=localhost port=5432 user=postgres password=secret dbname=testdb sslmode=disable statement_timeout=2000",
+		},
+		{
+			name: "password with space and quote",
+			cfg: config.DatabaseConfig{
+				Host:     "localhost",
+				Port:     5432,
+				User:     "postgres",
+				Password: "p@ss w'ord",
+				DBName:   "testdb",
+				SSLMode:  "disable",
+			},
+			want: `host=localhost port=5432 user=postgres password='p@ss w\'ord' dbname=testdb sslmode=disable`,
+		},
+		{
+			name: "password with backslash and equals",
+			cfg: config.DatabaseConfig{
+				Host:     "localhost",
+				Port:     5432,
+				User:     "postgres",
+				Password: `a\b=c`,
+				DBName:   "testdb",
+				SSLMode:  "disable",
+			},
+			want: `host=localhost port=5432 user=postgres password='a\\b=c' dbname=testdb sslmode=disable`,
+		},
+		{
+			name: "empty password",
+			cfg: config.DatabaseConfig{
+				Host:    "localhost",
+				Port:    5432,
+				User:    "postgres",
+				DBName:  "testdb",
+				SSLMode: "disable",
+			},
+			want: "host=localhost port=5432 user=postgres password='' dbname=testdb sslmode=disable",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// We can't actually test the connection string directly
-			// since it's built inside New(), but we can verify the format
-			dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-				tt.cfg.Host, tt.cfg.Port, tt.cfg.User, tt.cfg.Password, tt.cfg.DBName, tt.cfg.SSLMode)
-
-			if dsn != tt.want {
+			if dsn := buildDSN(tt.cfg); dsn != tt.want {
 				t.Errorf("connection string = %v, want %v", dsn, tt.want)
 			}
 		})
 	}
 }
+
+func TestConnMaxLifetime(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter int
+		random float64
+		want   time.Duration
+	}{
+		{name: "no jitter", jitter: 0, random: 0.9, want: 10 * time.Minute},
+		{name: "lowest", jitter: 10, random: 0, want: 9 * time.Minute},
+		{name: "middle", jitter: 10, random: 0.5, want: 10 * time.Minute},
+		{name: "near highest", jitter: 10, random: 0.75, want: 10*time.Minute + 30*time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DatabaseConfig{ConnMaxLifetime: 10, ConnLifetimeJitter: tt.jitter}
+			got := connMaxLifetime(cfg, func() float64 { return tt.random })
+			if got != tt.want {
+				t.Errorf("connMaxLifetime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnMaxLifetime_WithinRange(t *testing.T) {
+	cfg := config.DatabaseConfig{ConnMaxLifetime: 5, ConnLifetimeJitter: 20}
+	lower := 4 * time.Minute
+	upper := 6 * time.Minute
+
+	for i := 0; i < 1000; i++ {
+		got := connMaxLifetime(cfg, rand.Float64)
+		if got < lower || got > upper {
+			t.Fatalf("connMaxLifetime() = %v, want within [%v, %v]", got, lower, upper)
+		}
+	}
+}
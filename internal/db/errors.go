@@ -0,0 +1,37 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// PostgreSQL SQLSTATE codes used for classification.
+const (
+	codeUniqueViolation      = "23505"
+	codeForeignKeyViolation  = "23503"
+	codeSerializationFailure = "40001"
+)
+
+// IsUniqueViolation reports whether err is a unique constraint violation,
+// typically mapped to 409 Conflict.
+func IsUniqueViolation(err error) bool {
+	return hasCode(err, codeUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err references a row that does not
+// exist, typically mapped to 400 Bad Request.
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, codeForeignKeyViolation)
+}
+
+// IsSerializationFailure reports whether a transaction was aborted due to
+// concurrent updates and can be safely retried.
+func IsSerializationFailure(err error) bool {
+	return hasCode(err, codeSerializationFailure)
+}
+
+func hasCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		unique        bool
+		foreignKey    bool
+		serialization bool
+	}{
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, unique: true},
+		{name: "foreign key violation", err: &pq.Error{Code: "23503"}, foreignKey: true},
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, serialization: true},
+		{name: "wrapped unique violation", err: fmt.Errorf("insert user: %w", &pq.Error{Code: "23505"}), unique: true},
+		{name: "other pq error", err: &pq.Error{Code: "42P01"}},
+		{name: "non pq error", err: errors.New("connection refused")},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUniqueViolation(tt.err); got != tt.unique {
+				t.Errorf("IsUniqueViolation() = %v, want %v", got, tt.unique)
+			}
+			if got := IsForeignKeyViolation(tt.err); got != tt.foreignKey {
+				t.Errorf("IsForeignKeyViolation() = %v, want %v", got, tt.foreignKey)
+			}
+			if got := IsSerializationFailure(tt.err); got != tt.serialization {
+				t.Errorf("IsSerializationFailure() = %v, want %v", got, tt.serialization)
+			}
+		})
+	}
+}
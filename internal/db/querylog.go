@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// redactedParam replaces query parameters that are not allowlisted.
+const redactedParam = "REDACTED"
+
+type loggedParamsKey struct{}
+
+// LogParams allowlists the parameters at positions (1-based, matching $1,
+// $2, ...) to be logged in full by query logging for queries run with the
+// returned context. Use it only for parameters known to hold no sensitive
+// data; all others are logged as REDACTED.
+func LogParams(ctx context.Context, positions ...int) context.Context {
+	allowed := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		allowed[p] = true
+	}
+	return context.WithValue(ctx, loggedParamsKey{}, allowed)
+}
+
+// redactParams renders args for logging, redacting every parameter not
+// allowlisted in ctx.
+func redactParams(ctx context.Context, args []interface{}) []string {
+	allowed, _ := ctx.Value(loggedParamsKey{}).(map[int]bool)
+
+	params := make([]string, len(args))
+	for i, arg := range args {
+		if allowed[i+1] {
+			params[i] = fmt.Sprint(arg)
+		} else {
+			params[i] = redactedParam
+		}
+	}
+	return params
+}
+
+// logQuery logs an executed statement at debug level when query logging
+// is enabled.
+func (db *DB) logQuery(ctx context.Context, op, query string, args []interface{}, start time.Time, err error) {
+	if db.queryLogger == nil {
+		return
+	}
+
+	attrs := []any{
+		"op", op,
+		"sql", query,
+		"param_count", len(args),
+		"params", redactParams(ctx, args),
+		"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	db.queryLogger.DebugContext(ctx, "db query", attrs...)
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newLoggingDB returns a mock DB with query logging writing JSON to buf.
+func newLoggingDB(t *testing.T) (*DB, sqlmock.Sqlmock, *bytes.Buffer) {
+	t.Helper()
+
+	db, mock := newMockDB(t)
+	buf := &bytes.Buffer{}
+	db.queryLogger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return db, mock, buf
+}
+
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]interface{}
+	if err := json.Unmarshal(lines[len(lines)-1], &record); err != nil {
+		t.Fatalf("failed to decode log record %q: %v", lines[len(lines)-1], err)
+	}
+	return record
+}
+
+func TestDB_QueryLogging(t *testing.T) {
+	const query = "UPDATE users SET email = $1 WHERE id = $2"
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		wantParams []interface{}
+	}{
+		{
+			name:       "redacted by default",
+			ctx:        context.Background(),
+			wantParams: []interface{}{"REDACTED", "REDACTED"},
+		},
+		{
+			name:       "allowlisted position",
+			ctx:        LogParams(context.Background(), 2),
+			wantParams: []interface{}{"REDACTED", "42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, buf := newLoggingDB(t)
+			mock.ExpectExec("UPDATE users").WithArgs("alice@example.com", 42).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			if _, err := db.Exec(tt.ctx, query, "alice@example.com", 42); err != nil {
+				t.Fatalf("Exec() error = %v", err)
+			}
+
+			if bytes.Contains(buf.Bytes(), []byte("alice@example.com")) {
+				t.Fatalf("query log leaked a redacted parameter: %s", buf.String())
+			}
+
+			r := lastRecord(t, buf)
+			if r["level"] != "DEBUG" || r["msg"] != "db query" {
+				t.Errorf("record = %v, want debug db query", r)
+			}
+			if r["sql"] != query || r["op"] != "exec" {
+				t.Errorf("record sql/op = %v %v, want %q exec", r["sql"], r["op"], query)
+			}
+			if r["param_count"] != float64(2) {
+				t.Errorf("param_count = %v, want 2", r["param_count"])
+			}
+			params, _ := r["params"].([]interface{})
+			if len(params) != len(tt.wantParams) {
+				t.Fatalf("params = %v, want %v", r["params"], tt.wantParams)
+			}
+			for i, want := range tt.wantParams {
+				if params[i] != want {
+					t.Errorf("params[%d] = %v, want %v", i, params[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDB_QueryLoggingError(t *testing.T) {
+	db, mock, buf := newLoggingDB(t)
+	mock.ExpectQuery("SELECT name").WithArgs("secret").WillReturnError(errors.New("relation does not exist"))
+
+	if _, err := db.Query(context.Background(), "SELECT name FROM users WHERE token = $1", "secret"); err == nil {
+		t.Fatal("Query() error = nil, want error")
+	}
+
+	r := lastRecord(t, buf)
+	if r["op"] != "query" || r["error"] != "relation does not exist" {
+		t.Errorf("record = %v, want query op with error", r)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Errorf("query log leaked a redacted parameter: %s", buf.String())
+	}
+}
+
+func TestDB_QueryLoggingDisabled(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectExec("DELETE FROM sessions").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// No logger configured: Exec must not panic or log
+	if _, err := db.Exec(context.Background(), "DELETE FROM sessions"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+}
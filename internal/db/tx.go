@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+// WithTransaction runs fn in a transaction with default options. The
+// transaction is committed when fn returns nil and rolled back otherwise.
+func (db *DB) WithTransaction(ctx context.Context, fn func(*sql.Tx) error) error {
+	return db.WithTransactionOpts(ctx, nil, fn)
+}
+
+// WithTransactionOpts runs fn in a transaction started with opts, e.g. to
+// request sql.LevelSerializable. A panic in fn rolls back and re-panics.
+func (db *DB) WithTransactionOpts(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	tx, err := db.conn.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// WithTransactionRetry runs WithTransactionOpts, retrying the whole
+// transaction up to maxAttempts times while it fails with a serialization
+// failure. fn must be safe to run more than once.
+func (db *DB) WithTransactionRetry(ctx context.Context, opts *sql.TxOptions, maxAttempts int, fn func(*sql.Tx) error) error {
+	policy := retry.Policy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    200 * time.Millisecond,
+		Jitter:      0.5,
+		Retryable:   IsSerializationFailure,
+	}
+
+	return retry.Do(ctx, policy, func() error {
+		return db.WithTransactionOpts(ctx, opts, fn)
+	})
+}
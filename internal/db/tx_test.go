@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func newMockDB(t *testing.T) (*DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &DB{conn: conn}, mock
+}
+
+func TestDB_WithTransactionOpts_Serializable(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WithArgs(100, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := db.WithTransactionOpts(context.Background(), opts, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET balance = $1 WHERE id = $2", 100, 1)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTransactionOpts() error = %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDB_WithTransaction_RollbackOnError(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	errFn := errors.New("validation failed")
+	err := db.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("WithTransaction() error = %v, want %v", err, errFn)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDB_WithTransactionRetry_SerializationFailure(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	serializationErr := &pq.Error{Code: "40001", Message: "could not serialize access"}
+
+	// First attempt conflicts, second succeeds
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WillReturnError(serializationErr)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempts := 0
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := db.WithTransactionRetry(context.Background(), opts, 3, func(tx *sql.Tx) error {
+		attempts++
+		_, err := tx.Exec("UPDATE accounts SET balance = balance - 1")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTransactionRetry() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDB_WithTransactionRetry_GivesUp(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	serializationErr := &pq.Error{Code: "40001", Message: "could not serialize access"}
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+
+	attempts := 0
+	err := db.WithTransactionRetry(context.Background(), nil, 2, func(tx *sql.Tx) error {
+		attempts++
+		return serializationErr
+	})
+	if !IsSerializationFailure(err) {
+		t.Errorf("WithTransactionRetry() error = %v, want serialization failure", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestDB_WithTransactionRetry_OtherErrorNotRetried(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	attempts := 0
+	err := db.WithTransactionRetry(context.Background(), nil, 3, func(tx *sql.Tx) error {
+		attempts++
+		return &pq.Error{Code: "23505"}
+	})
+	if !IsUniqueViolation(err) {
+		t.Errorf("WithTransactionRetry() error = %v, want unique violation", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
@@ -2,7 +2,11 @@ package health
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,6 +15,12 @@ type Status string
 const (
 	StatusHealthy   Status = "healthy"
 	StatusUnhealthy Status = "unhealthy"
+	// StatusDegraded means only non-critical checks are failing; the
+	// service can still serve traffic.
+	StatusDegraded Status = "degraded"
+	// StatusMigrating means database migrations are still running; the
+	// service must not receive traffic yet.
+	StatusMigrating Status = "migrating"
 )
 
 type Check struct {
@@ -19,25 +29,244 @@ type Check struct {
 	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
+// Summary is a compact form of a Check for high-frequency probes: the
+// status and the names of failing checks only.
+type Summary struct {
+	Status  Status   `json:"status"`
+	Failing []string `json:"failing,omitempty"`
+}
+
+// Summary returns c without per-check details, listing the checks whose
+// status is not healthy in name order.
+func (c Check) Summary() Summary {
+	var failing []string
+	for name, detail := range c.Details {
+		d, ok := detail.(map[string]interface{})
+		if ok && d["status"] != string(StatusHealthy) {
+			failing = append(failing, name)
+		}
+	}
+	sort.Strings(failing)
+
+	return Summary{Status: c.Status, Failing: failing}
+}
+
 type Checker interface {
 	Ping(ctx context.Context) error
 }
 
+// defaultTimeout bounds a readiness run when no timeout is configured.
+const defaultTimeout = 5 * time.Second
+
+// LivenessSignal reports a condition that should make the process restart.
+type LivenessSignal func() error
+
 type Health struct {
-	checks map[string]Checker
-	mu     sync.RWMutex
+	checks        map[string]Checker
+	nonCritical   map[string]bool
+	liveness      map[string]LivenessSignal
+	groups        map[string][]string
+	timeout       time.Duration
+	requireChecks bool
+	shuttingDown  atomic.Bool
+	started       atomic.Bool
+	migrating     atomic.Bool
+	lastReadiness atomic.Pointer[Check]
+	mu            sync.RWMutex
+
+	// statuses holds the latest result of each registered check from any
+	// Readiness or ReadinessGroup run.
+	statusMu sync.Mutex
+	statuses map[string]checkStatus
+}
+
+// checkStatus is the outcome of one check and when it was pinged.
+type checkStatus struct {
+	status  Status
+	checked time.Time
 }
 
 func New(db Checker, kafka Checker) *Health {
-	return &Health{
-		checks: map[string]Checker{
-			"database": db,
-			"kafka":    kafka,
-		},
+	h := &Health{
+		checks:  make(map[string]Checker),
+		timeout: defaultTimeout,
+	}
+	h.Register("database", db)
+	h.Register("kafka", kafka)
+	return h
+}
+
+// RegisterOption configures a check added with Register.
+type RegisterOption func(*registration)
+
+type registration struct {
+	critical bool
+}
+
+// NonCritical marks a check whose failure degrades readiness instead of
+// making it unhealthy, e.g. a cache the service can run without.
+func NonCritical() RegisterOption {
+	return func(r *registration) {
+		r.critical = false
+	}
+}
+
+// Register adds a dependency checked by Readiness and reported under name
+// in its details. Checks are critical unless NonCritical is given.
+// Registering an existing name replaces its checker.
+func (h *Health) Register(name string, c Checker, opts ...RegisterOption) {
+	reg := registration{critical: true}
+	for _, opt := range opts {
+		opt(&reg)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.checks == nil {
+		h.checks = make(map[string]Checker)
+	}
+	h.checks[name] = c
+
+	if reg.critical {
+		delete(h.nonCritical, name)
+		return
+	}
+	if h.nonCritical == nil {
+		h.nonCritical = make(map[string]bool)
+	}
+	h.nonCritical[name] = true
+}
+
+// SetTimeout sets how long Readiness waits for all checks to respond.
+func (h *Health) SetTimeout(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if d > 0 {
+		h.timeout = d
+	}
+}
+
+// SetRequireChecks makes Readiness report unhealthy when no checks are
+// registered, surfacing a misconfigured service instead of masking it.
+func (h *Health) SetRequireChecks(require bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requireChecks = require
+}
+
+// RegisterLiveness adds a signal evaluated by Liveness. Any signal
+// returning an error makes the process report unhealthy.
+func (h *Health) RegisterLiveness(name string, signal LivenessSignal) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.liveness == nil {
+		h.liveness = make(map[string]LivenessSignal)
 	}
+	h.liveness[name] = signal
 }
 
 func (h *Health) Liveness() Check {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var details map[string]interface{}
+	for name, signal := range h.liveness {
+		if err := signal(); err != nil {
+			if details == nil {
+				details = make(map[string]interface{})
+			}
+			details[name] = map[string]interface{}{
+				"status": "unhealthy",
+				"error":  err.Error(),
+			}
+		}
+	}
+
+	status := StatusHealthy
+	if details != nil {
+		status = StatusUnhealthy
+	}
+
+	return Check{
+		Status:    status,
+		Timestamp: time.Now(),
+		Details:   details,
+	}
+}
+
+// MarkStarted records that initial dependency connections succeeded.
+// Startup reports healthy from then on.
+func (h *Health) MarkStarted() {
+	h.started.Store(true)
+}
+
+// Startup reports unhealthy until MarkStarted is called, so a slow cold
+// start trips the startup probe rather than liveness.
+func (h *Health) Startup() Check {
+	if !h.started.Load() {
+		return Check{
+			Status:    StatusUnhealthy,
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"started": false,
+			},
+		}
+	}
+
+	return Check{
+		Status:    StatusHealthy,
+		Timestamp: time.Now(),
+	}
+}
+
+// MarkMigrating makes readiness report StatusMigrating until MarkMigrated
+// is called. Liveness is unaffected so a long migration does not get the
+// process restarted.
+func (h *Health) MarkMigrating() {
+	h.migrating.Store(true)
+}
+
+// MarkMigrated records that migrations completed.
+func (h *Health) MarkMigrated() {
+	h.migrating.Store(false)
+}
+
+func migratingCheck() Check {
+	return Check{
+		Status:    StatusMigrating,
+		Timestamp: time.Now(),
+		Details: map[string]interface{}{
+			"migrating": true,
+		},
+	}
+}
+
+// MarkShuttingDown makes Shallow report unhealthy so load balancers stop
+// routing new traffic while the server drains.
+func (h *Health) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Shallow reports whether the process is serving and not shutting down,
+// without pinging dependencies. It is cheap enough for frequent polling.
+func (h *Health) Shallow() Check {
+	if h.shuttingDown.Load() {
+		return Check{
+			Status:    StatusUnhealthy,
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"shutting_down": true,
+			},
+		}
+	}
+	if h.migrating.Load() {
+		return migratingCheck()
+	}
+
 	return Check{
 		Status:    StatusHealthy,
 		Timestamp: time.Now(),
@@ -45,33 +274,136 @@ func (h *Health) Liveness() Check {
 }
 
 func (h *Health) Readiness(ctx context.Context) Check {
+	if h.migrating.Load() {
+		return migratingCheck()
+	}
+
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	if len(h.checks) == 0 && h.requireChecks {
+		return Check{
+			Status:    StatusUnhealthy,
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"no_checks_registered": true,
+			},
+		}
+	}
 
-	allHealthy := true
-	details := make(map[string]interface{})
+	check := h.run(ctx, h.checks)
+	h.lastReadiness.Store(&check)
+	return check
+}
 
-	for name, checker := range h.checks {
-		if err := checker.Ping(ctx); err != nil {
-			allHealthy = false
-			details[name] = map[string]interface{}{
-				"status": "unhealthy",
-				"error":  err.Error(),
-			}
+// Readiness groups for the read and write sides of the service.
+const (
+	GroupRead  = "read"
+	GroupWrite = "write"
+)
+
+// ErrUnknownGroup is returned by ReadinessGroup for a group that was never
+// defined with SetGroup.
+var ErrUnknownGroup = errors.New("unknown readiness group")
+
+// SetGroup defines a readiness group covering only the named checks, so
+// e.g. a read group without the Kafka producer keeps read traffic flowing
+// during a producer outage. Setting an existing group replaces it.
+func (h *Health) SetGroup(group string, checks ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.groups == nil {
+		h.groups = make(map[string][]string)
+	}
+	h.groups[group] = checks
+}
+
+// ReadinessGroup is Readiness limited to the checks in group. A check
+// named in the group but never registered reports unhealthy. Group runs do
+// not update LastReadiness.
+func (h *Health) ReadinessGroup(ctx context.Context, group string) (Check, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names, ok := h.groups[group]
+	if !ok {
+		return Check{}, fmt.Errorf("%w: %s", ErrUnknownGroup, group)
+	}
+	if h.migrating.Load() {
+		return migratingCheck(), nil
+	}
+
+	checks := make(map[string]Checker, len(names))
+	for _, name := range names {
+		if c, ok := h.checks[name]; ok {
+			checks[name] = c
 		} else {
-			details[name] = map[string]interface{}{
-				"status": "healthy",
-			}
+			checks[name] = unregistered(name)
 		}
 	}
+	return h.run(ctx, checks), nil
+}
 
-	status := StatusHealthy
-	if !allHealthy {
-		status = StatusUnhealthy
+// unregistered fails every ping for a group member with no registered
+// check, surfacing a misconfigured group instead of skipping it.
+type unregistered string
+
+func (u unregistered) Ping(ctx context.Context) error {
+	return fmt.Errorf("check %s is not registered", string(u))
+}
+
+// run pings checks concurrently and aggregates their results. The caller
+// must hold h.mu for reading.
+func (h *Health) run(ctx context.Context, checks map[string]Checker) Check {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	// Ping every dependency concurrently so readiness takes as long as the
+	// slowest check rather than the sum of them
+	var (
+		resultsMu sync.Mutex
+		wg        sync.WaitGroup
+		status    = StatusHealthy
+		details   = make(map[string]interface{}, len(checks))
+	)
+	for name, checker := range checks {
+		critical := !h.nonCritical[name]
+		_, unknown := checker.(unregistered)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := checker.Ping(ctx)
+			detail := map[string]interface{}{
+				"status":     "healthy",
+				"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				detail["status"] = "unhealthy"
+				detail["error"] = err.Error()
+			}
+			if !unknown {
+				h.recordStatus(name, Status(detail["status"].(string)), start)
+			}
+			if !critical {
+				detail["critical"] = false
+			}
+
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+			switch {
+			case err == nil:
+			case critical:
+				status = StatusUnhealthy
+			case status == StatusHealthy:
+				status = StatusDegraded
+			}
+			details[name] = detail
+		}()
 	}
+	wg.Wait()
 
 	return Check{
 		Status:    status,
@@ -79,3 +411,41 @@ func (h *Health) Readiness(ctx context.Context) Check {
 		Details:   details,
 	}
 }
+
+// LastReadiness returns the result of the most recent Readiness run, if
+// any, without running the checks again.
+func (h *Health) LastReadiness() (Check, bool) {
+	last := h.lastReadiness.Load()
+	if last == nil {
+		return Check{}, false
+	}
+	return *last, true
+}
+
+// recordStatus keeps the result of a check for LastStatus, unless a
+// concurrent run already recorded a newer one.
+func (h *Health) recordStatus(name string, status Status, checked time.Time) {
+	h.statusMu.Lock()
+	defer h.statusMu.Unlock()
+
+	if h.statuses == nil {
+		h.statuses = make(map[string]checkStatus)
+	}
+	if prev, ok := h.statuses[name]; ok && prev.checked.After(checked) {
+		return
+	}
+	h.statuses[name] = checkStatus{status: status, checked: checked}
+}
+
+// LastStatus returns the status of the named check from the most recent
+// Readiness or ReadinessGroup run that pinged it, and when it was pinged,
+// without pinging it again. Handlers can use it to skip work that depends
+// on a failing non-critical dependency, treating an old result as
+// unknown. ok is false before the check has run or for an unknown check.
+func (h *Health) LastStatus(name string) (status Status, checked time.Time, ok bool) {
+	h.statusMu.Lock()
+	defer h.statusMu.Unlock()
+
+	last, ok := h.statuses[name]
+	return last.status, last.checked, ok
+}
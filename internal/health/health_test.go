@@ -2,6 +2,7 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -39,6 +40,36 @@ func TestHealth_Liveness(t *testing.T) {
 	}
 }
 
+func TestHealth_LivenessSignals(t *testing.T) {
+	h := New(&mockChecker{}, &mockChecker{})
+
+	failing := false
+	h.RegisterLiveness("panics", func() error {
+		if failing {
+			return fmt.Errorf("too many panics")
+		}
+		return nil
+	})
+
+	if check := h.Liveness(); check.Status != StatusHealthy || check.Details != nil {
+		t.Errorf("Liveness() = %+v, want healthy without details", check)
+	}
+
+	failing = true
+	check := h.Liveness()
+	if check.Status != StatusUnhealthy {
+		t.Errorf("Liveness() status = %v, want %v", check.Status, StatusUnhealthy)
+	}
+
+	detail, ok := check.Details["panics"].(map[string]interface{})
+	if !ok {
+		t.Fatal("panics detail should exist and be a map")
+	}
+	if detail["error"] != "too many panics" {
+		t.Errorf("panics error = %v, want %q", detail["error"], "too many panics")
+	}
+}
+
 func TestHealth_Readiness(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -182,3 +213,339 @@ func (s *slowMockChecker) Ping(ctx context.Context) error {
 		return nil
 	}
 }
+
+type sleepingChecker struct {
+	delay time.Duration
+}
+
+func (s *sleepingChecker) Ping(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(s.delay):
+		return nil
+	}
+}
+
+func TestHealth_ReadinessParallel(t *testing.T) {
+	h := New(&sleepingChecker{delay: 400 * time.Millisecond}, &sleepingChecker{delay: 400 * time.Millisecond})
+
+	start := time.Now()
+	check := h.Readiness(context.Background())
+	duration := time.Since(start)
+
+	if duration >= 700*time.Millisecond {
+		t.Errorf("Readiness() took %v, want checks to run in parallel", duration)
+	}
+	if check.Status != StatusHealthy {
+		t.Errorf("Readiness() status = %v, want %v", check.Status, StatusHealthy)
+	}
+	if len(check.Details) != 2 {
+		t.Errorf("Readiness() details = %v, want 2 checks", check.Details)
+	}
+	for name, d := range check.Details {
+		latency, ok := d.(map[string]interface{})["latency_ms"].(float64)
+		if !ok || latency < 400 {
+			t.Errorf("%s latency_ms = %v, want at least 400", name, latency)
+		}
+	}
+}
+
+func TestHealth_SetTimeout(t *testing.T) {
+	h := New(&slowMockChecker{}, &mockChecker{})
+	h.SetTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	check := h.Readiness(context.Background())
+	duration := time.Since(start)
+
+	if duration > time.Second {
+		t.Errorf("Readiness() took %v, should timeout at 100ms", duration)
+	}
+	if check.Status != StatusUnhealthy {
+		t.Errorf("Readiness() status = %v, want %v", check.Status, StatusUnhealthy)
+	}
+}
+
+func TestHealth_ReadinessNoChecks(t *testing.T) {
+	tests := []struct {
+		name          string
+		requireChecks bool
+		wantStatus    Status
+		wantDetail    bool
+	}{
+		{
+			name:          "empty registry allowed",
+			requireChecks: false,
+			wantStatus:    StatusHealthy,
+			wantDetail:    false,
+		},
+		{
+			name:          "empty registry required",
+			requireChecks: true,
+			wantStatus:    StatusUnhealthy,
+			wantDetail:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Health{
+				checks:  map[string]Checker{},
+				timeout: defaultTimeout,
+			}
+			h.SetRequireChecks(tt.requireChecks)
+
+			check := h.Readiness(context.Background())
+
+			if check.Status != tt.wantStatus {
+				t.Errorf("Readiness() status = %v, want %v", check.Status, tt.wantStatus)
+			}
+
+			_, hasDetail := check.Details["no_checks_registered"]
+			if hasDetail != tt.wantDetail {
+				t.Errorf("Readiness() no_checks_registered present = %v, want %v", hasDetail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+type countingChecker struct {
+	pings int
+}
+
+func (c *countingChecker) Ping(ctx context.Context) error {
+	c.pings++
+	return fmt.Errorf("dependency down")
+}
+
+func TestHealth_Shallow(t *testing.T) {
+	db := &countingChecker{}
+	kafka := &countingChecker{}
+	h := New(db, kafka)
+
+	check := h.Shallow()
+	if check.Status != StatusHealthy {
+		t.Errorf("Shallow() status = %v, want %v", check.Status, StatusHealthy)
+	}
+	if db.pings != 0 || kafka.pings != 0 {
+		t.Errorf("Shallow() pinged dependencies: db=%d kafka=%d", db.pings, kafka.pings)
+	}
+
+	h.MarkShuttingDown()
+
+	check = h.Shallow()
+	if check.Status != StatusUnhealthy {
+		t.Errorf("Shallow() status after shutdown = %v, want %v", check.Status, StatusUnhealthy)
+	}
+	if check.Details["shutting_down"] != true {
+		t.Errorf("Shallow() details = %v, want shutting_down", check.Details)
+	}
+}
+
+func TestHealth_LastReadiness(t *testing.T) {
+	db := &mockChecker{}
+	h := New(db, &mockChecker{})
+
+	if _, ok := h.LastReadiness(); ok {
+		t.Fatal("LastReadiness() reported a result before any readiness run")
+	}
+
+	h.Readiness(context.Background())
+	last, ok := h.LastReadiness()
+	if !ok || last.Status != StatusHealthy {
+		t.Errorf("LastReadiness() = %v, %v, want healthy", last.Status, ok)
+	}
+
+	db.shouldFail = true
+	db.err = fmt.Errorf("connection refused")
+	h.Readiness(context.Background())
+	if last, _ := h.LastReadiness(); last.Status != StatusUnhealthy {
+		t.Errorf("LastReadiness() status = %v, want %v", last.Status, StatusUnhealthy)
+	}
+}
+
+func TestHealth_LastStatus(t *testing.T) {
+	enrichment := &mockChecker{}
+	h := New(&mockChecker{}, &mockChecker{})
+	h.Register("enrichment", enrichment, NonCritical())
+	h.SetGroup(GroupRead, "enrichment", "missing")
+
+	if _, _, ok := h.LastStatus("enrichment"); ok {
+		t.Fatal("LastStatus() reported a status before any readiness run")
+	}
+
+	h.Readiness(context.Background())
+	status, first, ok := h.LastStatus("enrichment")
+	if !ok || status != StatusHealthy {
+		t.Errorf("LastStatus(enrichment) = %v, %v, want healthy", status, ok)
+	}
+	if first.IsZero() {
+		t.Error("LastStatus(enrichment) returned no check time")
+	}
+	_, dbChecked, _ := h.LastStatus("database")
+
+	enrichment.shouldFail = true
+	enrichment.err = fmt.Errorf("timeout")
+	// The cached status only changes once the check runs again
+	if status, _, _ := h.LastStatus("enrichment"); status != StatusHealthy {
+		t.Errorf("LastStatus(enrichment) = %v before rerun, want cached healthy", status)
+	}
+
+	// A group run refreshes the checks it pings but no others
+	time.Sleep(time.Millisecond)
+	if _, err := h.ReadinessGroup(context.Background(), GroupRead); err != nil {
+		t.Fatalf("ReadinessGroup() error = %v", err)
+	}
+	status, checked, ok := h.LastStatus("enrichment")
+	if !ok || status != StatusUnhealthy {
+		t.Errorf("LastStatus(enrichment) = %v, %v after group run, want unhealthy", status, ok)
+	}
+	if !checked.After(first) {
+		t.Errorf("LastStatus(enrichment) checked at %v, want after %v", checked, first)
+	}
+	if _, got, _ := h.LastStatus("database"); !got.Equal(dbChecked) {
+		t.Errorf("LastStatus(database) checked at %v, want %v untouched by group run", got, dbChecked)
+	}
+
+	h.Readiness(context.Background())
+	if status, _, ok := h.LastStatus("database"); !ok || status != StatusHealthy {
+		t.Errorf("LastStatus(database) = %v, %v, want healthy", status, ok)
+	}
+	if _, _, ok := h.LastStatus("missing"); ok {
+		t.Error("LastStatus() reported a status for an unregistered group member")
+	}
+}
+
+func TestHealth_Register(t *testing.T) {
+	h := &Health{timeout: defaultTimeout}
+	h.Register("redis", &mockChecker{})
+	h.Register("billing-api", &mockChecker{shouldFail: true, err: fmt.Errorf("503 from billing")})
+	h.Register("s3", &mockChecker{})
+
+	check := h.Readiness(context.Background())
+
+	if check.Status != StatusUnhealthy {
+		t.Errorf("Readiness() status = %v, want %v", check.Status, StatusUnhealthy)
+	}
+
+	want := map[string]string{
+		"redis":       "healthy",
+		"billing-api": "unhealthy",
+		"s3":          "healthy",
+	}
+	if len(check.Details) != len(want) {
+		t.Fatalf("Readiness() details = %v, want %d checks", check.Details, len(want))
+	}
+	for name, status := range want {
+		detail, ok := check.Details[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("Readiness() missing detail for %s", name)
+			continue
+		}
+		if detail["status"] != status {
+			t.Errorf("%s status = %v, want %v", name, detail["status"], status)
+		}
+	}
+}
+
+func TestHealth_ReadinessDegraded(t *testing.T) {
+	tests := []struct {
+		name          string
+		criticalFails bool
+		optionalFails bool
+		want          Status
+	}{
+		{name: "all healthy", want: StatusHealthy},
+		{name: "non-critical failing", optionalFails: true, want: StatusDegraded},
+		{name: "critical failing", criticalFails: true, want: StatusUnhealthy},
+		{name: "both failing", criticalFails: true, optionalFails: true, want: StatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Health{timeout: defaultTimeout}
+			h.Register("database", &mockChecker{shouldFail: tt.criticalFails, err: fmt.Errorf("db down")})
+			h.Register("cache", &mockChecker{shouldFail: tt.optionalFails, err: fmt.Errorf("cache down")}, NonCritical())
+
+			check := h.Readiness(context.Background())
+
+			if check.Status != tt.want {
+				t.Errorf("Readiness() status = %v, want %v", check.Status, tt.want)
+			}
+			cache := check.Details["cache"].(map[string]interface{})
+			if cache["critical"] != false {
+				t.Errorf("cache critical = %v, want false", cache["critical"])
+			}
+			if _, ok := check.Details["database"].(map[string]interface{})["critical"]; ok {
+				t.Error("critical check should not report a critical field")
+			}
+		})
+	}
+}
+
+func TestHealth_Startup(t *testing.T) {
+	h := New(&mockChecker{}, &mockChecker{})
+
+	if got := h.Startup().Status; got != StatusUnhealthy {
+		t.Errorf("Startup() before MarkStarted = %v, want %v", got, StatusUnhealthy)
+	}
+
+	h.MarkStarted()
+
+	if got := h.Startup().Status; got != StatusHealthy {
+		t.Errorf("Startup() after MarkStarted = %v, want %v", got, StatusHealthy)
+	}
+}
+
+func TestHealth_ReadinessGroup(t *testing.T) {
+	kafka := &mockChecker{shouldFail: true, err: fmt.Errorf("producer down")}
+	h := New(&mockChecker{}, kafka)
+	h.SetGroup(GroupRead, "database")
+	h.SetGroup(GroupWrite, "database", "kafka")
+	h.SetGroup("typo", "databse")
+
+	tests := []struct {
+		name       string
+		group      string
+		wantStatus Status
+		wantChecks []string
+		wantErr    bool
+	}{
+		{name: "read ignores kafka", group: GroupRead, wantStatus: StatusHealthy, wantChecks: []string{"database"}},
+		{name: "write includes kafka", group: GroupWrite, wantStatus: StatusUnhealthy, wantChecks: []string{"database", "kafka"}},
+		{name: "unregistered check", group: "typo", wantStatus: StatusUnhealthy, wantChecks: []string{"databse"}},
+		{name: "unknown group", group: "admin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := h.ReadinessGroup(context.Background(), tt.group)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownGroup) {
+					t.Errorf("ReadinessGroup() error = %v, want ErrUnknownGroup", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadinessGroup() error = %v", err)
+			}
+
+			if check.Status != tt.wantStatus {
+				t.Errorf("ReadinessGroup() status = %v, want %v", check.Status, tt.wantStatus)
+			}
+			if len(check.Details) != len(tt.wantChecks) {
+				t.Errorf("ReadinessGroup() details = %v, want %v", check.Details, tt.wantChecks)
+			}
+			for _, name := range tt.wantChecks {
+				if _, ok := check.Details[name]; !ok {
+					t.Errorf("ReadinessGroup() missing detail for %s", name)
+				}
+			}
+		})
+	}
+
+	if _, ok := h.LastReadiness(); ok {
+		t.Error("ReadinessGroup() updated LastReadiness")
+	}
+}
@@ -0,0 +1,93 @@
+package id
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatUUIDv4 = "uuidv4"
+	FormatUUIDv7 = "uuidv7"
+	FormatBase62 = "base62"
+)
+
+// base62Length gives tokens roughly 131 bits of randomness, comparable to
+// a UUIDv4.
+const base62Length = 22
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Generator produces unique identifiers for requests, messages and keys.
+type Generator interface {
+	New() string
+}
+
+// NewGenerator returns the Generator for format, one of FormatUUIDv4,
+// FormatUUIDv7 or FormatBase62.
+func NewGenerator(format string) (Generator, error) {
+	switch format {
+	case FormatUUIDv4:
+		return UUIDv4(), nil
+	case FormatUUIDv7:
+		return UUIDv7(), nil
+	case FormatBase62:
+		return Base62(), nil
+	default:
+		return nil, fmt.Errorf("unknown id format: %s", format)
+	}
+}
+
+type uuidV4Generator struct{}
+
+// UUIDv4 returns a Generator producing random UUIDs.
+func UUIDv4() Generator {
+	return uuidV4Generator{}
+}
+
+func (uuidV4Generator) New() string {
+	return uuid.NewString()
+}
+
+type uuidV7Generator struct{}
+
+// UUIDv7 returns a Generator producing time-ordered UUIDs, which sort by
+// creation time and index well as database keys.
+func UUIDv7() Generator {
+	return uuidV7Generator{}
+}
+
+func (uuidV7Generator) New() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+type base62Generator struct{}
+
+// Base62 returns a Generator producing short URL-safe random tokens.
+func Base62() Generator {
+	return base62Generator{}
+}
+
+func (base62Generator) New() string {
+	token := make([]byte, 0, base62Length)
+	buf := make([]byte, base62Length*2)
+
+	for len(token) < base62Length {
+		if _, err := rand.Read(buf); err != nil {
+			panic(fmt.Sprintf("id: reading random bytes: %v", err))
+		}
+		for _, b := range buf {
+			// Reject values past the largest multiple of 62 to avoid bias
+			if b >= 248 {
+				continue
+			}
+			token = append(token, base62Alphabet[b%62])
+			if len(token) == base62Length {
+				break
+			}
+		}
+	}
+
+	return string(token)
+}
@@ -0,0 +1,60 @@
+package id
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		format  string
+		pattern string
+		wantErr bool
+	}{
+		{format: FormatUUIDv4, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{format: FormatUUIDv7, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{format: FormatBase62, pattern: `^[0-9A-Za-z]{22}$`},
+		{format: "snowflake", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			gen, err := NewGenerator(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGenerator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			re := regexp.MustCompile(tt.pattern)
+			seen := make(map[string]bool)
+			for i := 0; i < 1000; i++ {
+				id := gen.New()
+				if !re.MatchString(id) {
+					t.Fatalf("New() = %q, does not match %s", id, tt.pattern)
+				}
+				if seen[id] {
+					t.Fatalf("New() returned duplicate id %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestUUIDv7_TimeOrdered(t *testing.T) {
+	gen := UUIDv7()
+
+	ids := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		ids = append(ids, gen.New())
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("UUIDv7 ids not in creation order: %v", ids)
+	}
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/rest"
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+// registryPolicy retries Avro serialization through brief Schema Registry
+// outages. Incompatible schemas and invalid values fail on the first try.
+var registryPolicy = retry.Policy{
+	MaxAttempts: 4,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.2,
+	Retryable:   isTransientRegistryError,
+}
+
+// isTransientRegistryError reports whether err is a Schema Registry
+// timeout, throttle or server error worth retrying. Registry error codes
+// are either HTTP statuses or their five-digit refinements (e.g. 50001).
+func isTransientRegistryError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var restErr *rest.Error
+	if errors.As(err, &restErr) {
+		status := restErr.Code
+		if status >= 10000 {
+			status /= 100
+		}
+		return status >= 500 || status == 408 || status == 429
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// avroEncoder is the part of the Schema Registry serializer used to
+// produce Avro values.
+type avroEncoder interface {
+	Serialize(topic string, msg interface{}) ([]byte, error)
+}
+
+// serializeAvro serializes value, retrying transient registry errors
+// according to policy.
+func serializeAvro(ctx context.Context, encoder avroEncoder, policy retry.Policy, subject string, value interface{}) ([]byte, error) {
+	var payload []byte
+	err := retry.Do(ctx, policy, func() error {
+		var err error
+		payload, err = encoder.Serialize(subject, value)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize avro message: %w", err)
+	}
+	return payload, nil
+}
+
+// avroDecoder is the part of the Schema Registry deserializer used to fill
+// typed values.
+type avroDecoder interface {
+	DeserializeInto(topic string, payload []byte, msg interface{}) error
+}
+
+// ConsumeAvroInto consumes the configured topic, deserializing each Avro
+// value into a fresh pointer from newTarget (e.g. func() interface{} {
+// return &Order{} }) before passing it to handler. Messages that fail to
+// deserialize are not retried; they are skipped or dead-lettered at once
+// according to the handler failure policy.
+func (c *Client) ConsumeAvroInto(ctx context.Context, newTarget func() interface{}, handler func(interface{}) error) error {
+	if c.avroDeserializer == nil {
+		return fmt.Errorf("avro deserializer not initialized")
+	}
+
+	return c.ConsumeMessages(ctx, c.avroIntoHandler(c.avroDeserializer, newTarget, handler))
+}
+
+func (c *Client) avroIntoHandler(decoder avroDecoder, newTarget func() interface{}, handler func(interface{}) error) MessageHandler {
+	return func(msg Message) error {
+		target := newTarget()
+		if err := decoder.DeserializeInto(msg.Topic, msg.Value, target); err != nil {
+			return retry.Permanent(fmt.Errorf("failed to deserialize avro message: %w", err))
+		}
+		return handler(target)
+	}
+}
+
+// avroGenericDecoder is the part of the Schema Registry deserializer used
+// to decode values without a target type.
+type avroGenericDecoder interface {
+	Deserialize(topic string, payload []byte) (interface{}, error)
+}
+
+// ConsumeAvroMessages consumes the configured topic, passing handler each
+// message key and its Avro value decoded generically (records become
+// map[string]interface{}). Messages that fail to deserialize are skipped
+// or dead-lettered without retrying, like ConsumeAvroInto.
+func (c *Client) ConsumeAvroMessages(ctx context.Context, handler func(key []byte, value interface{}) error) error {
+	if c.avroDeserializer == nil {
+		return fmt.Errorf("avro deserializer not initialized")
+	}
+
+	return c.ConsumeMessages(ctx, c.avroGenericHandler(c.avroDeserializer, handler))
+}
+
+func (c *Client) avroGenericHandler(decoder avroGenericDecoder, handler func(key []byte, value interface{}) error) MessageHandler {
+	return func(msg Message) error {
+		value, err := decoder.Deserialize(msg.Topic, msg.Value)
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("failed to deserialize avro message: %w", err))
+		}
+		return handler(msg.Key, value)
+	}
+}
+
+// serializeAvroKeyValue serializes key under keySubject with keyEncoder and
+// value under valueSubject with valueEncoder.
+func serializeAvroKeyValue(ctx context.Context, keyEncoder, valueEncoder avroEncoder, key, value interface{}, keySubject, valueSubject string) ([]byte, []byte, error) {
+	serializedKey, err := serializeAvro(ctx, keyEncoder, registryPolicy, keySubject, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("key: %w", err)
+	}
+
+	serializedValue, err := serializeAvro(ctx, valueEncoder, registryPolicy, valueSubject, value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("value: %w", err)
+	}
+	return serializedKey, serializedValue, nil
+}
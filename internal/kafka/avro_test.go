@@ -0,0 +1,297 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/rest"
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+type order struct {
+	ID     string  `avro:"id"`
+	Amount float64 `avro:"amount"`
+}
+
+// jsonDecoder stands in for the Avro deserializer, decoding JSON payloads
+// into the supplied target.
+type jsonDecoder struct {
+	topics []string
+}
+
+func (d *jsonDecoder) DeserializeInto(topic string, payload []byte, msg interface{}) error {
+	d.topics = append(d.topics, topic)
+	return json.Unmarshal(payload, msg)
+}
+
+func TestClient_AvroIntoHandler(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+	decoder := &jsonDecoder{}
+
+	var got []*order
+	handler := client.avroIntoHandler(decoder,
+		func() interface{} { return &order{} },
+		func(v interface{}) error {
+			got = append(got, v.(*order))
+			return nil
+		})
+
+	for _, payload := range []string{`{"ID":"o-1","Amount":9.5}`, `{"ID":"o-2","Amount":3}`} {
+		if err := handler(Message{Topic: "orders", Value: []byte(payload)}); err != nil {
+			t.Fatalf("handler() error = %v", err)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 decoded orders, got %d", len(got))
+	}
+	if got[0] == got[1] {
+		t.Error("expected a fresh target per message")
+	}
+	if got[0].ID != "o-1" || got[0].Amount != 9.5 || got[1].ID != "o-2" {
+		t.Errorf("unexpected decoded orders: %+v, %+v", got[0], got[1])
+	}
+	if len(decoder.topics) != 2 || decoder.topics[0] != "orders" {
+		t.Errorf("expected deserializer called with topic orders, got %v", decoder.topics)
+	}
+}
+
+func TestClient_AvroIntoHandlerDeserializeError(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+
+	handler := client.avroIntoHandler(&jsonDecoder{},
+		func() interface{} { return &order{} },
+		func(v interface{}) error {
+			t.Error("handler should not be called when deserialization fails")
+			return nil
+		})
+
+	err := handler(Message{Topic: "orders", Value: []byte("not avro")})
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("handler() error = %v, want wrapped deserialize error", err)
+	}
+	if retry.Retryable(err) {
+		t.Errorf("handler() error = %v, want a permanent error", err)
+	}
+}
+
+func TestClient_ConsumeAvroIntoNotInitialized(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+
+	err := client.ConsumeAvroInto(context.Background(),
+		func() interface{} { return &order{} },
+		func(interface{}) error { return nil })
+	if err == nil {
+		t.Error("expected error when avro deserializer is not initialized")
+	}
+}
+
+func (d *jsonDecoder) Deserialize(topic string, payload []byte) (interface{}, error) {
+	d.topics = append(d.topics, topic)
+	var value interface{}
+	err := json.Unmarshal(payload, &value)
+	return value, err
+}
+
+func TestClient_AvroGenericHandler(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+	decoder := &jsonDecoder{}
+
+	var gotKey []byte
+	var gotValue interface{}
+	handler := client.avroGenericHandler(decoder, func(key []byte, value interface{}) error {
+		gotKey, gotValue = key, value
+		return nil
+	})
+
+	msg := Message{Topic: "orders", Key: []byte("o-1"), Value: []byte(`{"id":"o-1","amount":9.5}`)}
+	if err := handler(msg); err != nil {
+		t.Fatalf("handler() error = %v", err)
+	}
+
+	if string(gotKey) != "o-1" {
+		t.Errorf("key = %q, want o-1", gotKey)
+	}
+	record, ok := gotValue.(map[string]interface{})
+	if !ok || record["id"] != "o-1" || record["amount"] != 9.5 {
+		t.Errorf("value = %#v, want decoded record", gotValue)
+	}
+	if len(decoder.topics) != 1 || decoder.topics[0] != "orders" {
+		t.Errorf("expected deserializer called with topic orders, got %v", decoder.topics)
+	}
+
+	if err := handler(Message{Topic: "orders", Value: []byte("not avro")}); err == nil {
+		t.Error("expected handler() to fail when deserialization fails")
+	}
+}
+
+func TestClient_ConsumeAvroMessagesNotInitialized(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+
+	err := client.ConsumeAvroMessages(context.Background(), func([]byte, interface{}) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "avro deserializer not initialized") {
+		t.Errorf("ConsumeAvroMessages() error = %v, want not initialized error", err)
+	}
+}
+
+// flakyEncoder fails with errs in turn before succeeding.
+type flakyEncoder struct {
+	errs  []error
+	calls int
+}
+
+func (e *flakyEncoder) Serialize(topic string, msg interface{}) ([]byte, error) {
+	e.calls++
+	if e.calls <= len(e.errs) {
+		return nil, e.errs[e.calls-1]
+	}
+	return []byte("avro"), nil
+}
+
+func TestSerializeAvro(t *testing.T) {
+	policy := registryPolicy
+	policy.BaseDelay = time.Millisecond
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "transient then success",
+			errs:      []error{&rest.Error{Code: 50001, Message: "store error"}, &rest.Error{Code: 503, Message: "unavailable"}},
+			wantCalls: 3,
+		},
+		{
+			name:      "incompatible schema fails fast",
+			errs:      []error{&rest.Error{Code: 409, Message: "incompatible schema"}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "invalid value fails fast",
+			errs:      []error{errors.New("missing field amount")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name: "transient until attempts run out",
+			errs: []error{
+				&rest.Error{Code: 500}, &rest.Error{Code: 500}, &rest.Error{Code: 500}, &rest.Error{Code: 500},
+			},
+			wantCalls: 4,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder := &flakyEncoder{errs: tt.errs}
+
+			payload, err := serializeAvro(context.Background(), encoder, policy, "orders-value", &order{ID: "o-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeAvro() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(payload) != "avro" {
+				t.Errorf("serializeAvro() payload = %q, want avro", payload)
+			}
+			if encoder.calls != tt.wantCalls {
+				t.Errorf("Serialize called %d times, want %d", encoder.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIsTransientRegistryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &rest.Error{Code: 500}, true},
+		{"refined server error", &rest.Error{Code: 50003}, true},
+		{"throttled", &rest.Error{Code: 429}, true},
+		{"incompatible", &rest.Error{Code: 409}, false},
+		{"refined not found", &rest.Error{Code: 40401}, false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"context deadline", context.DeadlineExceeded, false},
+		{"other", errors.New("bad value"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientRegistryError(tt.err); got != tt.want {
+				t.Errorf("isTransientRegistryError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingEncoder records the subject each value was serialized under.
+type recordingEncoder struct {
+	prefix   string
+	subjects []string
+}
+
+func (e *recordingEncoder) Serialize(topic string, msg interface{}) ([]byte, error) {
+	e.subjects = append(e.subjects, topic)
+	return json.Marshal(map[string]interface{}{e.prefix: msg})
+}
+
+func TestSerializeAvroKeyValue(t *testing.T) {
+	keys := &recordingEncoder{prefix: "key"}
+	values := &recordingEncoder{prefix: "value"}
+
+	key, value, err := serializeAvroKeyValue(context.Background(), keys, values,
+		map[string]string{"id": "o-1"}, &order{ID: "o-1", Amount: 2},
+		"orders-key", "orders-value")
+	if err != nil {
+		t.Fatalf("serializeAvroKeyValue() error = %v", err)
+	}
+
+	if string(key) != `{"key":{"id":"o-1"}}` {
+		t.Errorf("key = %s, want key encoder output", key)
+	}
+	if string(value) != `{"value":{"ID":"o-1","Amount":2}}` {
+		t.Errorf("value = %s, want value encoder output", value)
+	}
+	if len(keys.subjects) != 1 || keys.subjects[0] != "orders-key" {
+		t.Errorf("key subjects = %v, want [orders-key]", keys.subjects)
+	}
+	if len(values.subjects) != 1 || values.subjects[0] != "orders-value" {
+		t.Errorf("value subjects = %v, want [orders-value]", values.subjects)
+	}
+}
+
+func TestSerializeAvroKeyValue_KeyError(t *testing.T) {
+	keys := &flakyEncoder{errs: []error{&rest.Error{Code: 409, Message: "incompatible"}}}
+	values := &recordingEncoder{prefix: "value"}
+
+	_, _, err := serializeAvroKeyValue(context.Background(), keys, values, "k", "v", "orders-key", "orders-value")
+
+	var restErr *rest.Error
+	if !errors.As(err, &restErr) {
+		t.Errorf("serializeAvroKeyValue() error = %v, want wrapped registry error", err)
+	}
+	if len(values.subjects) != 0 {
+		t.Error("value should not be serialized when the key fails")
+	}
+}
+
+func TestClient_SendAvroMessageWithKeyNotInitialized(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+
+	err := client.SendAvroMessageWithKey(context.Background(), "orders", "k", "v", "orders-key", "orders-value")
+	if err == nil {
+		t.Error("expected error when avro serializers are not initialized")
+	}
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+// SendBatch enqueues msgs in order and flushes the producer once, waiting
+// until ctx's deadline or the delivery timeout, whichever is sooner. Messages
+// with the same key go to the same partition in slice order, and the
+// idempotent producer keeps that order on retries. If a message cannot be
+// enqueued, the rest of the batch is not sent so no later message
+// overtakes it. The returned error joins the failure of every message that
+// was not delivered.
+func (c *Client) SendBatch(ctx context.Context, msgs []Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return fmt.Errorf("client is closed")
+	}
+	if !c.produces() {
+		return c.roleError("producer")
+	}
+	if c.producer == nil {
+		return fmt.Errorf("producer not initialized")
+	}
+
+	timeout := c.cfg.DeliveryTimeout
+	if timeout <= 0 {
+		timeout = defaultDeliveryTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	// Every report fits in the buffer, so none blocks the producer even
+	// if we stop waiting early
+	deliveryChan := make(chan kafka.Event, len(msgs))
+
+	var errs []error
+	enqueued := 0
+	for i, msg := range msgs {
+		kafkaMsg := c.toKafkaMessage(withContextHeaders(ctx, msg))
+		kafkaMsg.Opaque = i
+
+		err := retry.Do(ctx, producePolicy, func() error {
+			return c.producer.Produce(kafkaMsg, deliveryChan)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("message %d: failed to produce message: %w", i, err))
+			if i+1 < len(msgs) {
+				errs = append(errs, fmt.Errorf("messages %d-%d: not sent after earlier failure", i+1, len(msgs)-1))
+			}
+			break
+		}
+		enqueued++
+	}
+
+	c.producer.Flush(int(time.Until(deadline).Milliseconds()))
+
+	// Collect the delivery reports. Flush has normally delivered them all;
+	// waiting until the deadline covers reports still being dispatched.
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	delivered := make([]bool, enqueued)
+	for received := 0; received < enqueued; received++ {
+		select {
+		case e := <-deliveryChan:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				received--
+				continue
+			}
+			i, _ := m.Opaque.(int)
+			delivered[i] = true
+			if m.TopicPartition.Error != nil {
+				errs = append(errs, fmt.Errorf("message %d: message delivery failed: %w", i, m.TopicPartition.Error))
+			}
+		case <-ctx.Done():
+			errs = append(errs, undelivered(delivered, ctx.Err()))
+			return errors.Join(errs...)
+		case <-timer.C:
+			errs = append(errs, undelivered(delivered, ErrDeliveryTimeout))
+			return errors.Join(errs...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	c.logger.Debug("message batch sent successfully", "messages", len(msgs))
+	return nil
+}
+
+// undelivered reports the messages still awaiting a delivery report.
+func undelivered(delivered []bool, cause error) error {
+	var pending []int
+	for i, ok := range delivered {
+		if !ok {
+			pending = append(pending, i)
+		}
+	}
+	return fmt.Errorf("messages %v: %w", pending, cause)
+}
@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/testutil"
+)
+
+// newMockProducer returns a producer-only client connected to an
+// in-process mock cluster with topic created.
+func newMockProducer(t *testing.T, topic string, partitions int, deliveryTimeout time.Duration) (*Client, *kafka.MockCluster) {
+	t.Helper()
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to create mock cluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+
+	if err := cluster.CreateTopic(topic, partitions, 1); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	client, err := New(config.KafkaConfig{
+		Brokers:          []string{cluster.BootstrapServers()},
+		Topic:            topic,
+		SecurityProtocol: "PLAINTEXT",
+		Role:             RoleProducer,
+		DeliveryTimeout:  deliveryTimeout,
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	return client, cluster
+}
+
+func TestClient_SendBatch(t *testing.T) {
+	const topic = "batch"
+	const partitions = 3
+	const n = 50
+
+	client, _ := newMockProducer(t, topic, partitions, 10*time.Second)
+
+	msgs := make([]Message, n)
+	for i := range msgs {
+		msgs[i] = Message{
+			Key:   []byte(fmt.Sprintf("key-%d", i%5)),
+			Value: []byte(fmt.Sprintf("value-%d", i)),
+		}
+	}
+
+	if err := client.SendBatch(context.Background(), msgs); err != nil {
+		t.Fatalf("SendBatch() error = %v", err)
+	}
+	if queued := client.producer.Len(); queued != 0 {
+		t.Errorf("expected producer queue flushed, %d events remain", queued)
+	}
+
+	var total int64
+	for p := int32(0); p < partitions; p++ {
+		_, high, err := client.producer.QueryWatermarkOffsets(topic, p, 5000)
+		if err != nil {
+			t.Fatalf("QueryWatermarkOffsets() error = %v", err)
+		}
+		total += high
+	}
+	if total != n {
+		t.Errorf("expected %d messages on the topic, got %d", n, total)
+	}
+}
+
+func TestClient_SendBatchDeliveryFailure(t *testing.T) {
+	client, cluster := newMockProducer(t, "batch", 1, 200*time.Millisecond)
+
+	if err := cluster.SetBrokerDown(1); err != nil {
+		t.Fatalf("SetBrokerDown() error = %v", err)
+	}
+
+	msgs := []Message{{Value: []byte("a")}, {Value: []byte("b")}, {Value: []byte("c")}}
+	err := client.SendBatch(context.Background(), msgs)
+	if err == nil {
+		t.Fatal("expected SendBatch() to fail with the broker down")
+	}
+	if !strings.Contains(err.Error(), "[0 1 2]") || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("SendBatch() error = %q, want every message reported undelivered", err)
+	}
+
+	// Let librdkafka give up on the messages before the client closes
+	if err := client.producer.Purge(kafka.PurgeQueue | kafka.PurgeInFlight); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+}
+
+func TestClient_SendBatchEmpty(t *testing.T) {
+	client := &Client{}
+	if err := client.SendBatch(context.Background(), nil); err != nil {
+		t.Errorf("SendBatch() error = %v, want nil for an empty batch", err)
+	}
+}
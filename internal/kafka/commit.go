@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+// offsetBatcher accumulates the highest processed offset per partition so
+// commits can be sent every maxMessages messages or every interval rather
+// than after each message.
+type offsetBatcher struct {
+	maxMessages int
+	interval    time.Duration
+	now         func() time.Time
+
+	pending   map[partitionKey]kafka.TopicPartition
+	count     int
+	lastFlush time.Time
+}
+
+func newOffsetBatcher(maxMessages int, interval time.Duration) *offsetBatcher {
+	return &offsetBatcher{
+		maxMessages: maxMessages,
+		interval:    interval,
+		now:         time.Now,
+		pending:     make(map[partitionKey]kafka.TopicPartition),
+		lastFlush:   time.Now(),
+	}
+}
+
+// add records tp as processed. The committed offset is the next one to read.
+func (b *offsetBatcher) add(tp kafka.TopicPartition) {
+	key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+	next := tp.Offset + 1
+
+	if existing, ok := b.pending[key]; !ok || next > existing.Offset {
+		b.pending[key] = kafka.TopicPartition{
+			Topic:     tp.Topic,
+			Partition: tp.Partition,
+			Offset:    next,
+		}
+	}
+	b.count++
+}
+
+// due reports whether pending offsets should be committed now.
+func (b *offsetBatcher) due() bool {
+	if len(b.pending) == 0 {
+		return false
+	}
+	if b.maxMessages > 0 && b.count >= b.maxMessages {
+		return true
+	}
+	return b.interval > 0 && b.now().Sub(b.lastFlush) >= b.interval
+}
+
+// take returns the pending offsets and resets the batch.
+func (b *offsetBatcher) take() []kafka.TopicPartition {
+	offsets := make([]kafka.TopicPartition, 0, len(b.pending))
+	for _, tp := range b.pending {
+		offsets = append(offsets, tp)
+	}
+
+	b.pending = make(map[partitionKey]kafka.TopicPartition)
+	b.count = 0
+	b.lastFlush = b.now()
+	return offsets
+}
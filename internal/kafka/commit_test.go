@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func testPartition(topic string, partition int32, offset kafka.Offset) kafka.TopicPartition {
+	return kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}
+}
+
+func TestOffsetBatcher_CountTrigger(t *testing.T) {
+	b := newOffsetBatcher(3, 0)
+
+	b.add(testPartition("events", 0, 10))
+	b.add(testPartition("events", 0, 11))
+	if b.due() {
+		t.Fatal("batch should not be due before reaching the message count")
+	}
+
+	b.add(testPartition("events", 1, 5))
+	if !b.due() {
+		t.Fatal("batch should be due after reaching the message count")
+	}
+
+	offsets := b.take()
+	if len(offsets) != 2 {
+		t.Fatalf("expected offsets for 2 partitions, got %d", len(offsets))
+	}
+
+	want := map[int32]kafka.Offset{0: 12, 1: 6}
+	for _, tp := range offsets {
+		if tp.Offset != want[tp.Partition] {
+			t.Errorf("partition %d offset = %v, want %v", tp.Partition, tp.Offset, want[tp.Partition])
+		}
+	}
+
+	if b.due() {
+		t.Error("batch should be empty after take")
+	}
+}
+
+func TestOffsetBatcher_TimeTrigger(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newOffsetBatcher(0, time.Second)
+	b.now = func() time.Time { return now }
+	b.lastFlush = now
+
+	if b.due() {
+		t.Fatal("empty batch should never be due")
+	}
+
+	b.add(testPartition("events", 0, 1))
+	if b.due() {
+		t.Fatal("batch should not be due before the interval elapses")
+	}
+
+	now = now.Add(time.Second)
+	if !b.due() {
+		t.Fatal("batch should be due once the interval elapses")
+	}
+
+	b.take()
+	b.add(testPartition("events", 0, 2))
+	if b.due() {
+		t.Error("interval should restart after take")
+	}
+}
+
+func TestOffsetBatcher_KeepsHighestOffset(t *testing.T) {
+	b := newOffsetBatcher(10, 0)
+
+	b.add(testPartition("events", 0, 20))
+	b.add(testPartition("events", 0, 15))
+
+	offsets := b.take()
+	if len(offsets) != 1 || offsets[0].Offset != 21 {
+		t.Errorf("expected single offset 21, got %v", offsets)
+	}
+}
@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/id"
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+const (
+	EventFormatJSON = "json"
+	EventFormatAvro = "avro"
+)
+
+// Event is the standard envelope for messages exchanged between services.
+// Data is left raw so handlers can decode it into their own types.
+type Event struct {
+	ID         string          `json:"id" avro:"id"`
+	Type       string          `json:"type" avro:"type"`
+	Version    int             `json:"version" avro:"version"`
+	OccurredAt time.Time       `json:"occurred_at" avro:"occurred_at"`
+	Data       json.RawMessage `json:"data" avro:"data"`
+}
+
+type EventHandler func(Event) error
+
+// SendEvent encodes event in the configured format and produces it to
+// topic. A missing ID or OccurredAt is filled in.
+func (c *Client) SendEvent(ctx context.Context, topic string, event Event) error {
+	if event.ID == "" {
+		event.ID = c.newID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	if topic == "" {
+		topic = c.cfg.Topic
+	}
+
+	value, err := c.encodeEvent(topic, event)
+	if err != nil {
+		return err
+	}
+
+	return c.SendMessage(ctx, Message{
+		Topic: topic,
+		Key:   []byte(event.ID),
+		Value: value,
+	})
+}
+
+// newID returns an event ID, falling back to UUIDv7 for clients built
+// without New.
+func (c *Client) newID() string {
+	if c.idGen == nil {
+		return id.UUIDv7().New()
+	}
+	return c.idGen.New()
+}
+
+// ConsumeEvents consumes the configured topic, decoding each message into
+// an Event. Events whose version differs from expectedVersion are still
+// delivered but logged as a warning; an expectedVersion of zero disables
+// the check. Messages that fail to decode are skipped or dead-lettered
+// without retrying.
+func (c *Client) ConsumeEvents(ctx context.Context, expectedVersion int, handler EventHandler) error {
+	return c.ConsumeMessages(ctx, c.eventHandler(expectedVersion, handler))
+}
+
+func (c *Client) eventHandler(expectedVersion int, handler EventHandler) MessageHandler {
+	return func(msg Message) error {
+		event, err := c.decodeEvent(msg)
+		if err != nil {
+			return err
+		}
+
+		if expectedVersion != 0 && event.Version != expectedVersion {
+			c.logger.Warn("event version mismatch",
+				"topic", msg.Topic,
+				"event_id", event.ID,
+				"event_type", event.Type,
+				"version", event.Version,
+				"expected_version", expectedVersion)
+		}
+
+		return handler(event)
+	}
+}
+
+func (c *Client) encodeEvent(topic string, event Event) ([]byte, error) {
+	if c.cfg.EventFormat == EventFormatAvro {
+		if c.avroSerializer == nil {
+			return nil, fmt.Errorf("avro serializer not initialized")
+		}
+		value, err := c.avroSerializer.Serialize(topic, &event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize avro event: %w", err)
+		}
+		return value, nil
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return value, nil
+}
+
+func (c *Client) decodeEvent(msg Message) (Event, error) {
+	var event Event
+
+	if c.cfg.EventFormat == EventFormatAvro {
+		if c.avroDeserializer == nil {
+			return Event{}, fmt.Errorf("avro deserializer not initialized")
+		}
+		if err := c.avroDeserializer.DeserializeInto(msg.Topic, msg.Value, &event); err != nil {
+			return Event{}, retry.Permanent(fmt.Errorf("failed to deserialize avro event: %w", err))
+		}
+		return event, nil
+	}
+
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return Event{}, retry.Permanent(fmt.Errorf("failed to unmarshal event: %w", err))
+	}
+	return event, nil
+}
@@ -0,0 +1,142 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/id"
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+func TestEvent_RoundTrip(t *testing.T) {
+	client := &Client{
+		cfg:    config.KafkaConfig{Topic: "test-topic", EventFormat: EventFormatJSON},
+		logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil)),
+	}
+
+	event := Event{
+		ID:         "evt-1",
+		Type:       "order.created",
+		Version:    2,
+		OccurredAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:       json.RawMessage(`{"order_id":"42"}`),
+	}
+
+	value, err := client.encodeEvent("test-topic", event)
+	if err != nil {
+		t.Fatalf("encodeEvent() error = %v", err)
+	}
+
+	got, err := client.decodeEvent(Message{Topic: "test-topic", Value: value})
+	if err != nil {
+		t.Fatalf("decodeEvent() error = %v", err)
+	}
+
+	if got.ID != event.ID || got.Type != event.Type || got.Version != event.Version {
+		t.Errorf("decodeEvent() = %+v, want %+v", got, event)
+	}
+	if !got.OccurredAt.Equal(event.OccurredAt) {
+		t.Errorf("decodeEvent() OccurredAt = %v, want %v", got.OccurredAt, event.OccurredAt)
+	}
+	if string(got.Data) != string(event.Data) {
+		t.Errorf("decodeEvent() Data = %s, want %s", got.Data, event.Data)
+	}
+}
+
+func TestEvent_AvroNotInitialized(t *testing.T) {
+	client := &Client{
+		cfg:    config.KafkaConfig{Topic: "test-topic", EventFormat: EventFormatAvro},
+		logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil)),
+	}
+
+	if _, err := client.encodeEvent("test-topic", Event{}); err == nil {
+		t.Error("expected encodeEvent() to fail without avro serializer")
+	}
+	if _, err := client.decodeEvent(Message{}); err == nil {
+		t.Error("expected decodeEvent() to fail without avro deserializer")
+	}
+}
+
+func TestClient_EventHandlerVersionMismatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		version         int
+		expectedVersion int
+		wantWarning     bool
+	}{
+		{name: "matching version", version: 1, expectedVersion: 1, wantWarning: false},
+		{name: "mismatched version", version: 2, expectedVersion: 1, wantWarning: true},
+		{name: "check disabled", version: 2, expectedVersion: 0, wantWarning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			client := &Client{
+				cfg:    config.KafkaConfig{Topic: "test-topic"},
+				logger: slog.New(slog.NewJSONHandler(buf, nil)),
+			}
+
+			value, err := json.Marshal(Event{ID: "evt-1", Type: "order.created", Version: tt.version})
+			if err != nil {
+				t.Fatalf("failed to marshal event: %v", err)
+			}
+
+			var handled Event
+			handler := client.eventHandler(tt.expectedVersion, func(e Event) error {
+				handled = e
+				return nil
+			})
+
+			if err := handler(Message{Topic: "test-topic", Value: value}); err != nil {
+				t.Fatalf("handler error = %v", err)
+			}
+
+			if handled.ID != "evt-1" {
+				t.Errorf("expected event to be delivered, got %+v", handled)
+			}
+
+			if got := strings.Contains(buf.String(), "event version mismatch"); got != tt.wantWarning {
+				t.Errorf("version mismatch warning logged = %v, want %v", got, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestClient_EventHandlerInvalidPayload(t *testing.T) {
+	client := &Client{
+		cfg:    config.KafkaConfig{Topic: "test-topic"},
+		logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil)),
+	}
+
+	handler := client.eventHandler(1, func(e Event) error {
+		t.Error("handler should not be called for an invalid payload")
+		return nil
+	})
+
+	err := handler(Message{Topic: "test-topic", Value: []byte("not json")})
+	if err == nil {
+		t.Fatal("expected error for invalid payload")
+	}
+	if retry.Retryable(err) {
+		t.Errorf("handler() error = %v, want a permanent error", err)
+	}
+}
+
+func TestClient_NewID(t *testing.T) {
+	client := &Client{}
+	if _, err := uuid.Parse(client.newID()); err != nil {
+		t.Errorf("newID() without generator is not a UUID: %v", err)
+	}
+
+	WithIDGenerator(id.Base62())(client)
+	if got := client.newID(); len(got) != 22 {
+		t.Errorf("newID() with base62 generator = %q, want 22 characters", got)
+	}
+}
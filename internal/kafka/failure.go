@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/retry"
+)
+
+// Handler failure policies applied once a message's retries are exhausted.
+const (
+	FailureSkip = "skip"
+	FailureDLQ  = "dlq"
+)
+
+// Headers added to dead-lettered messages recording where they came from
+// and why their handler failed.
+const (
+	HeaderDLQError     = "dlq-error"
+	HeaderDLQTopic     = "dlq-source-topic"
+	HeaderDLQPartition = "dlq-source-partition"
+	HeaderDLQOffset    = "dlq-source-offset"
+)
+
+// dlqSuffix names the default dead-letter topic for a source topic.
+const dlqSuffix = ".dlq"
+
+// handlerPolicy retries failed handlers with exponential backoff. Handlers
+// can return retry.Permanent to give up without retrying.
+func (c *Client) handlerPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: c.cfg.HandlerRetries + 1,
+		BaseDelay:   c.cfg.HandlerRetryBase,
+		MaxDelay:    c.cfg.HandlerRetryMax,
+	}
+}
+
+// handleWithRetry calls handler until it succeeds or its retries run out.
+// Cancelling ctx stops further retries but not an attempt in progress.
+func (c *Client) handleWithRetry(ctx context.Context, handler MessageHandler, msg Message, tp kafka.TopicPartition) error {
+	attempt := 0
+	return retry.Do(ctx, c.handlerPolicy(), func() error {
+		attempt++
+		err := c.callHandler(handler, msg, tp)
+		if err != nil {
+			c.logger.Warn("message handler attempt failed",
+				"topic", msg.Topic,
+				"partition", tp.Partition,
+				"offset", tp.Offset,
+				"attempt", attempt,
+				"error", err)
+		}
+		return err
+	})
+}
+
+// handleFailure applies the configured failure policy to a message whose
+// handler retries are exhausted, sending dead letters with send. It
+// reports whether the message's offset may be committed; a message that
+// could not be dead-lettered is left uncommitted for the consume loop to
+// redeliver.
+func (c *Client) handleFailure(ctx context.Context, send func(context.Context, Message) error, msg Message, tp kafka.TopicPartition, handlerErr error) bool {
+	if c.cfg.HandlerFailure != FailureDLQ {
+		c.logger.Error("skipping message after handler failed",
+			"topic", msg.Topic,
+			"partition", tp.Partition,
+			"offset", tp.Offset,
+			"error", handlerErr)
+		return true
+	}
+
+	dead := c.dlqMessage(msg, tp, handlerErr)
+	if err := send(context.WithoutCancel(ctx), dead); err != nil {
+		c.logger.Error("failed to send message to dlq",
+			"topic", msg.Topic,
+			"partition", tp.Partition,
+			"offset", tp.Offset,
+			"dlq_topic", dead.Topic,
+			"handler_error", handlerErr,
+			"error", err)
+		return false
+	}
+
+	c.logger.Warn("sent message to dlq",
+		"topic", msg.Topic,
+		"partition", tp.Partition,
+		"offset", tp.Offset,
+		"dlq_topic", dead.Topic,
+		"error", handlerErr)
+	return true
+}
+
+// dlqMessage copies msg for the dead-letter topic, adding headers that
+// describe its source and the handler error.
+func (c *Client) dlqMessage(msg Message, tp kafka.TopicPartition, handlerErr error) Message {
+	topic := c.cfg.DLQTopic
+	if topic == "" {
+		topic = msg.Topic + dlqSuffix
+	}
+
+	headers := make(map[string][]byte, len(msg.Headers)+4)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDLQError] = []byte(handlerErr.Error())
+	headers[HeaderDLQTopic] = []byte(msg.Topic)
+	headers[HeaderDLQPartition] = []byte(strconv.FormatInt(int64(tp.Partition), 10))
+	headers[HeaderDLQOffset] = []byte(strconv.FormatInt(int64(tp.Offset), 10))
+
+	return Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
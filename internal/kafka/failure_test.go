@@ -0,0 +1,249 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+)
+
+// failingHandler fails its first failures calls and then succeeds.
+func failingHandler(failures int, calls *int) MessageHandler {
+	return func(Message) error {
+		*calls++
+		if *calls <= failures {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}
+}
+
+func TestClient_HandleWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "succeeds on third attempt", retries: 3, wantCalls: 3},
+		{name: "retries exhausted", retries: 1, wantCalls: 2, wantErr: true},
+		{name: "no retries", retries: 0, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+				cfg: config.KafkaConfig{
+					HandlerRetries:   tt.retries,
+					HandlerRetryBase: time.Millisecond,
+					HandlerRetryMax:  2 * time.Millisecond,
+				},
+			}
+
+			topic := "orders"
+			tp := kafka.TopicPartition{Topic: &topic, Partition: 0, Offset: 7}
+			calls := 0
+			err := client.handleWithRetry(context.Background(), failingHandler(2, &calls), Message{Topic: topic}, tp)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handleWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClient_HandleWithRetryCancelled(t *testing.T) {
+	client := &Client{
+		logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+		cfg: config.KafkaConfig{
+			HandlerRetries:   3,
+			HandlerRetryBase: time.Hour,
+			HandlerRetryMax:  time.Hour,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	topic := "orders"
+	tp := kafka.TopicPartition{Topic: &topic}
+	calls := 0
+	handler := func(Message) error {
+		calls++
+		cancel()
+		return errors.New("downstream unavailable")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.handleWithRetry(ctx, handler, Message{Topic: topic}, tp)
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("handleWithRetry() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleWithRetry() kept backing off after cancellation")
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestClient_HandleFailure(t *testing.T) {
+	handlerErr := errors.New("invalid order")
+
+	tests := []struct {
+		name       string
+		policy     string
+		dlqTopic   string
+		sendErr    error
+		wantCommit bool
+		wantSent   bool
+		wantTopic  string
+	}{
+		{name: "skip", policy: FailureSkip, wantCommit: true},
+		{name: "default policy skips", policy: "", wantCommit: true},
+		{name: "dlq", policy: FailureDLQ, wantCommit: true, wantSent: true, wantTopic: "orders.dlq"},
+		{name: "configured dlq topic", policy: FailureDLQ, dlqTopic: "dead-letters", wantCommit: true, wantSent: true, wantTopic: "dead-letters"},
+		{name: "dlq send fails", policy: FailureDLQ, sendErr: errors.New("broker down"), wantCommit: false, wantSent: true, wantTopic: "orders.dlq"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+				cfg:    config.KafkaConfig{HandlerFailure: tt.policy, DLQTopic: tt.dlqTopic},
+			}
+
+			var sent []Message
+			send := func(ctx context.Context, msg Message) error {
+				sent = append(sent, msg)
+				return tt.sendErr
+			}
+
+			topic := "orders"
+			tp := kafka.TopicPartition{Topic: &topic, Partition: 2, Offset: 41}
+			msg := Message{
+				Topic:   topic,
+				Key:     []byte("order-1"),
+				Value:   []byte(`{"id":1}`),
+				Headers: map[string][]byte{"traceparent": []byte("00-abc")},
+			}
+
+			if got := client.handleFailure(context.Background(), send, msg, tp, handlerErr); got != tt.wantCommit {
+				t.Errorf("handleFailure() = %v, want %v", got, tt.wantCommit)
+			}
+
+			if !tt.wantSent {
+				if len(sent) != 0 {
+					t.Errorf("expected nothing sent, got %d messages", len(sent))
+				}
+				return
+			}
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 dlq message, got %d", len(sent))
+			}
+
+			dead := sent[0]
+			if dead.Topic != tt.wantTopic {
+				t.Errorf("dlq topic = %q, want %q", dead.Topic, tt.wantTopic)
+			}
+			if string(dead.Key) != "order-1" || string(dead.Value) != `{"id":1}` {
+				t.Errorf("dlq message = %q/%q, want original key and value", dead.Key, dead.Value)
+			}
+			wantHeaders := map[string]string{
+				"traceparent":      "00-abc",
+				HeaderDLQError:     "invalid order",
+				HeaderDLQTopic:     "orders",
+				HeaderDLQPartition: "2",
+				HeaderDLQOffset:    "41",
+			}
+			for k, want := range wantHeaders {
+				if got := string(dead.Headers[k]); got != want {
+					t.Errorf("header %s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_RedeliverAfterFailedDeadLetter(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "sequential", concurrency: 1},
+		{name: "concurrent", concurrency: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, cluster := newShutdownClient(t, "redeliver")
+			client.cfg.HandlerFailure = FailureDLQ
+			client.cfg.ConsumerConcurrency = tt.concurrency
+
+			// The first dead-letter send fails, as if the broker were down
+			var sends atomic.Int32
+			client.sendDeadLetter = func(ctx context.Context, msg Message) error {
+				if sends.Add(1) == 1 {
+					return errors.New("broker down")
+				}
+				return nil
+			}
+
+			var mu sync.Mutex
+			deliveries := make(map[string]int)
+			handler := func(msg Message) error {
+				mu.Lock()
+				defer mu.Unlock()
+				deliveries[string(msg.Value)]++
+				if string(msg.Value) == "1" {
+					return errors.New("invalid message")
+				}
+				return nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			consumed := make(chan error, 1)
+			go func() {
+				consumed <- client.ConsumeMessages(ctx, handler)
+			}()
+
+			deadline := time.Now().Add(30 * time.Second)
+			for committedOffset(t, cluster, "redeliver-group", "redeliver") != 3 {
+				if time.Now().After(deadline) {
+					t.Fatal("offsets were not committed through the last message")
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+			cancel()
+			<-consumed
+
+			mu.Lock()
+			defer mu.Unlock()
+			if deliveries["1"] != 2 {
+				t.Errorf("failed message delivered %d times, want 2", deliveries["1"])
+			}
+			if sends.Load() != 2 {
+				t.Errorf("dead-letter sends = %d, want 2", sends.Load())
+			}
+
+			if err := client.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errGraceExpired reports that a handler was still running when the
+// shutdown grace period ran out.
+var errGraceExpired = errors.New("shutdown grace period expired")
+
+// runWithGrace runs fn and waits for it to finish. Once ctx is cancelled fn
+// is given up to grace to complete before errGraceExpired is returned. fn
+// cannot be interrupted, so an abandoned call keeps running in the
+// background; its result is discarded.
+func runWithGrace(ctx context.Context, grace time.Duration, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return errGraceExpired
+	}
+}
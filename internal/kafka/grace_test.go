@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunWithGrace(t *testing.T) {
+	handlerErr := errors.New("handler failed")
+
+	tests := []struct {
+		name    string
+		work    time.Duration
+		result  error
+		grace   time.Duration
+		wantErr error
+	}{
+		{
+			name:  "finishes within grace",
+			work:  20 * time.Millisecond,
+			grace: 500 * time.Millisecond,
+		},
+		{
+			name:    "handler error within grace",
+			work:    20 * time.Millisecond,
+			result:  handlerErr,
+			grace:   500 * time.Millisecond,
+			wantErr: handlerErr,
+		},
+		{
+			name:    "exceeds grace",
+			work:    500 * time.Millisecond,
+			grace:   20 * time.Millisecond,
+			wantErr: errGraceExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			started := make(chan struct{})
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- runWithGrace(ctx, tt.grace, func() error {
+					close(started)
+					time.Sleep(tt.work)
+					return tt.result
+				})
+			}()
+
+			// Shutdown arrives while the handler is mid-message
+			<-started
+			cancel()
+
+			if err := <-errCh; !errors.Is(err, tt.wantErr) {
+				t.Errorf("runWithGrace() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunWithGrace_NoCancellation(t *testing.T) {
+	err := runWithGrace(context.Background(), 0, func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("runWithGrace() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,25 @@
+package kafka
+
+// Common message header keys.
+const (
+	// HeaderContentType is the media type of the message value, e.g.
+	// application/json.
+	HeaderContentType = "content-type"
+	// HeaderCorrelationID ties a message to the request or message that
+	// caused it.
+	HeaderCorrelationID = "correlation-id"
+)
+
+// SetHeader sets header key to value, creating the headers map if needed.
+func (m *Message) SetHeader(key, value string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string][]byte)
+	}
+	m.Headers[key] = []byte(value)
+}
+
+// GetHeader returns header key as a string and whether it was present.
+func (m *Message) GetHeader(key string) (string, bool) {
+	value, ok := m.Headers[key]
+	return string(value), ok
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import "testing"
+
+func TestMessage_SetHeader(t *testing.T) {
+	var msg Message
+	msg.SetHeader(HeaderContentType, "application/json")
+	msg.SetHeader(HeaderCorrelationID, "req-1")
+	msg.SetHeader(HeaderCorrelationID, "req-2")
+
+	if len(msg.Headers) != 2 {
+		t.Fatalf("expected 2 headers, got %d: %v", len(msg.Headers), msg.Headers)
+	}
+	if string(msg.Headers[HeaderCorrelationID]) != "req-2" {
+		t.Errorf("header %s = %q, want the last value set", HeaderCorrelationID, msg.Headers[HeaderCorrelationID])
+	}
+}
+
+func TestMessage_GetHeader(t *testing.T) {
+	msg := Message{Headers: map[string][]byte{
+		HeaderContentType: []byte("application/json"),
+		"empty":           {},
+	}}
+
+	tests := []struct {
+		name   string
+		msg    Message
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{name: "present", msg: msg, key: HeaderContentType, want: "application/json", wantOK: true},
+		{name: "present but empty", msg: msg, key: "empty", want: "", wantOK: true},
+		{name: "missing", msg: msg, key: HeaderCorrelationID, want: "", wantOK: false},
+		{name: "no headers", msg: Message{}, key: HeaderContentType, want: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.msg.GetHeader(tt.key)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("GetHeader(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMessage_HeaderRoundTrip(t *testing.T) {
+	var msg Message
+	msg.SetHeader(HeaderCorrelationID, "req-1")
+
+	// The headers survive conversion to and from the wire type
+	client := &Client{}
+	got := toMessage(client.toKafkaMessage(msg))
+
+	if value, ok := got.GetHeader(HeaderCorrelationID); !ok || value != "req-1" {
+		t.Errorf("GetHeader(%q) = %q, %v, want %q, true", HeaderCorrelationID, value, ok, "req-1")
+	}
+}
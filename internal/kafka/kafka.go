@@ -2,8 +2,10 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
@@ -13,19 +15,92 @@ import (
 	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/serde"
 	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/serde/avro"
 	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/id"
+	"github.com/sksmith/go-base-ms/internal/retry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// producePolicy retries Produce while the local producer queue is full,
+// giving librdkafka time to drain it.
+var producePolicy = retry.Policy{
+	MaxAttempts: 5,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    time.Second,
+	Jitter:      0.2,
+	Retryable:   isQueueFull,
+}
+
+func isQueueFull(err error) bool {
+	var kafkaErr kafka.Error
+	return errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrQueueFull
+}
+
+// Client roles selecting which side of the client New initializes.
+const (
+	RoleProducer = "producer"
+	RoleConsumer = "consumer"
+	RoleBoth     = "both"
+)
+
+// ErrRoleNotConfigured is returned by operations needing a producer or
+// consumer the client's role did not initialize.
+var ErrRoleNotConfigured = errors.New("not configured for this role")
+
+// defaultDeliveryTimeout bounds SendMessage when no timeout is configured.
+const defaultDeliveryTimeout = 30 * time.Second
+
+// ErrDeliveryTimeout is returned when no delivery report arrives within the
+// configured delivery timeout. The message may still be delivered later.
+var ErrDeliveryTimeout = errors.New("message delivery timeout")
+
+// defaultFlushTimeout bounds the flush in Close when no timeout is
+// configured.
+const defaultFlushTimeout = 10 * time.Second
+
 type Client struct {
 	producer         *kafka.Producer
 	consumer         *kafka.Consumer
 	schemaRegistry   schemaregistry.Client
 	avroSerializer   *avro.GenericSerializer
 	avroDeserializer *avro.GenericDeserializer
-	logger           *slog.Logger
-	cfg              config.KafkaConfig
-	srCfg            config.SchemaRegistryConfig
-	mu               sync.RWMutex
-	closed           bool
+	// avroKeySerializer and avroKeyDeserializer handle Avro-encoded keys,
+	// registered under key subjects.
+	avroKeySerializer   *avro.GenericSerializer
+	avroKeyDeserializer *avro.GenericDeserializer
+	// protobufSerializer and protobufDeserializer are set only when the
+	// serialization format is protobuf.
+	protobufSerializer   *ProtobufSerializer
+	protobufDeserializer *ProtobufDeserializer
+	logger               *slog.Logger
+	cfg                  config.KafkaConfig
+	srCfg                config.SchemaRegistryConfig
+	topicResolver        TopicResolver
+	idGen                id.Generator
+	offsetStore          OffsetStore
+	stats                *statsMetrics
+	tracer               trace.Tracer
+	// sendDeadLetter produces dead letters; it is SendMessage outside
+	// tests.
+	sendDeadLetter func(context.Context, Message) error
+	// stopConsume cancels the running consume loop and consumeDone is
+	// closed once it has exited, so Close can let it finish first.
+	stopConsume context.CancelFunc
+	consumeDone chan struct{}
+	mu          sync.RWMutex
+	closed      bool
+}
+
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithIDGenerator sets the generator used for event IDs.
+func WithIDGenerator(gen id.Generator) Option {
+	return func(c *Client) {
+		if gen != nil {
+			c.idGen = gen
+		}
+	}
 }
 
 type Message struct {
@@ -33,15 +108,40 @@ type Message struct {
 	Value   []byte
 	Headers map[string][]byte
 	Topic   string
+	// Timestamp, when non-zero, is sent as the message's event time
+	// (CreateTime). When zero the producer assigns the timestamp.
+	Timestamp time.Time
+
+	ctx context.Context
+}
+
+// Context returns the context a consumed message is handled in. It carries
+// the consume span, so passing it to SendMessage or other traced calls
+// keeps them in the message's trace. It is not cancelled when consuming
+// stops, letting a handler finish within the shutdown grace period. For
+// messages not delivered by a consumer it is context.Background().
+func (m *Message) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
 }
 
 type MessageHandler func(Message) error
 
-func New(kafkaCfg config.KafkaConfig, srCfg config.SchemaRegistryConfig, logger *slog.Logger) (*Client, error) {
+func New(kafkaCfg config.KafkaConfig, srCfg config.SchemaRegistryConfig, logger *slog.Logger, opts ...Option) (*Client, error) {
 	client := &Client{
-		logger: logger,
-		cfg:    kafkaCfg,
-		srCfg:  srCfg,
+		logger:        logger,
+		cfg:           kafkaCfg,
+		srCfg:         srCfg,
+		topicResolver: PatternTopicResolver(kafkaCfg.TenantTopicPrefix, kafkaCfg.TenantTopicSuffix),
+		idGen:         id.UUIDv7(),
+		tracer:        otel.Tracer(tracerName),
+	}
+	client.sendDeadLetter = client.SendMessage
+
+	for _, opt := range opts {
+		opt(client)
 	}
 
 	// Initialize Schema Registry client
@@ -49,19 +149,38 @@ func New(kafkaCfg config.KafkaConfig, srCfg config.SchemaRegistryConfig, logger
 		return nil, fmt.Errorf("failed to initialize schema registry: %w", err)
 	}
 
-	// Initialize Kafka producer
-	if err := client.initProducer(); err != nil {
-		return nil, fmt.Errorf("failed to initialize producer: %w", err)
+	// Initialize only the sides the configured role needs
+	if client.produces() {
+		if err := client.initProducer(); err != nil {
+			return nil, fmt.Errorf("failed to initialize producer: %w", err)
+		}
 	}
 
-	// Initialize Kafka consumer
-	if err := client.initConsumer(); err != nil {
-		return nil, fmt.Errorf("failed to initialize consumer: %w", err)
+	if client.consumes() {
+		if err := client.initConsumer(); err != nil {
+			return nil, fmt.Errorf("failed to initialize consumer: %w", err)
+		}
 	}
 
 	return client, nil
 }
 
+// produces reports whether the client's role includes producing. An empty
+// role means both.
+func (c *Client) produces() bool {
+	return c.cfg.Role != RoleConsumer
+}
+
+// consumes reports whether the client's role includes consuming.
+func (c *Client) consumes() bool {
+	return c.cfg.Role != RoleProducer
+}
+
+// roleError reports that side is not available for the client's role.
+func (c *Client) roleError(side string) error {
+	return fmt.Errorf("%s %w (role %s)", side, ErrRoleNotConfigured, c.cfg.Role)
+}
+
 func (c *Client) initSchemaRegistry() error {
 	if c.srCfg.URL == "" {
 		c.logger.Warn("schema registry URL not configured, skipping initialization")
@@ -97,7 +216,22 @@ func (c *Client) initSchemaRegistry() error {
 		return fmt.Errorf("failed to create avro deserializer: %w", err)
 	}
 
-	c.logger.Info("schema registry initialized", "url", c.srCfg.URL)
+	c.avroKeySerializer, err = avro.NewGenericSerializer(c.schemaRegistry, serde.KeySerde, avro.NewSerializerConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create avro key serializer: %w", err)
+	}
+
+	c.avroKeyDeserializer, err = avro.NewGenericDeserializer(c.schemaRegistry, serde.KeySerde, avro.NewDeserializerConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create avro key deserializer: %w", err)
+	}
+
+	if c.cfg.SerializationFormat == SerializationProtobuf {
+		c.protobufSerializer = NewProtobufSerializer(c.schemaRegistry)
+		c.protobufDeserializer = NewProtobufDeserializer(c.schemaRegistry)
+	}
+
+	c.logger.Info("schema registry initialized", "url", c.srCfg.URL, "format", c.cfg.SerializationFormat)
 	return nil
 }
 
@@ -110,6 +244,7 @@ func (c *Client) initProducer() error {
 		"max.in.flight.requests.per.connection": 5,
 		"enable.idempotence":                    true,
 	}
+	c.statsConfig(configMap)
 
 	// Add security configuration
 	if c.cfg.SecurityProtocol != "PLAINTEXT" {
@@ -136,11 +271,12 @@ func (c *Client) initProducer() error {
 	return nil
 }
 
-func (c *Client) initConsumer() error {
+// consumerConfig builds the consumer configuration for groupID.
+func (c *Client) consumerConfig(groupID string) kafka.ConfigMap {
 	configMap := kafka.ConfigMap{
 		"bootstrap.servers":  strings.Join(c.cfg.Brokers, ","),
 		"client.id":          "go-base-ms-consumer",
-		"group.id":           c.cfg.GroupID,
+		"group.id":           groupID,
 		"auto.offset.reset":  "earliest",
 		"enable.auto.commit": false,
 	}
@@ -157,6 +293,13 @@ func (c *Client) initConsumer() error {
 		}
 	}
 
+	return configMap
+}
+
+func (c *Client) initConsumer() error {
+	configMap := c.consumerConfig(c.cfg.GroupID)
+	c.statsConfig(configMap)
+
 	var err error
 	c.consumer, err = kafka.NewConsumer(&configMap)
 	if err != nil {
@@ -171,6 +314,9 @@ func (c *Client) handleDeliveryReports() {
 	for e := range c.producer.Events() {
 		switch ev := e.(type) {
 		case *kafka.Message:
+			if cb, ok := ev.Opaque.(deliveryCallback); ok {
+				cb(ev.TopicPartition.Error)
+			}
 			if ev.TopicPartition.Error != nil {
 				c.logger.Error("delivery failed",
 					"topic", *ev.TopicPartition.Topic,
@@ -182,19 +328,40 @@ func (c *Client) handleDeliveryReports() {
 					"partition", ev.TopicPartition.Partition,
 					"offset", ev.TopicPartition.Offset)
 			}
+		case *kafka.Stats:
+			c.handleStats(ev.String())
 		}
 	}
 }
 
+// Close stops a running consume loop, letting it finish and commit the
+// message it is handling, flushes messages still queued for delivery, then
+// closes the producer and consumer. It returns an error if messages were
+// left undelivered when the flush timeout expired.
 func (c *Client) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if c.closed {
+		c.mu.Unlock()
 		return nil
 	}
-
 	c.closed = true
+	stop, done := c.stopConsume, c.consumeDone
+	c.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+
+	// Flush without holding the lock so delivery callbacks that touch the
+	// client can still run; closed already rejects new sends
+	var err error
+	if c.producer != nil {
+		err = c.flush()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	if c.producer != nil {
 		c.producer.Close()
@@ -204,6 +371,24 @@ func (c *Client) Close() error {
 	}
 
 	c.logger.Info("kafka client closed")
+	return err
+}
+
+// flush waits up to the flush timeout for queued and in-flight messages to
+// be delivered, returning an error if any remain.
+func (c *Client) flush() error {
+	timeout := c.cfg.FlushTimeout
+	if timeout <= 0 {
+		timeout = defaultFlushTimeout
+	}
+
+	c.producer.Flush(int(timeout.Milliseconds()))
+	if remaining := c.producer.Len(); remaining > 0 {
+		c.logger.Warn("producer closed with unflushed messages",
+			"remaining", remaining,
+			"timeout", timeout)
+		return fmt.Errorf("%d messages not flushed within %s", remaining, timeout)
+	}
 	return nil
 }
 
@@ -215,12 +400,18 @@ func (c *Client) Ping(ctx context.Context) error {
 		return fmt.Errorf("client is closed")
 	}
 
-	if c.producer == nil {
-		return fmt.Errorf("producer not initialized")
+	// Get metadata to check connection through whichever side the role
+	// initialized
+	var metadata *kafka.Metadata
+	var err error
+	switch {
+	case c.producer != nil:
+		metadata, err = c.producer.GetMetadata(nil, false, 5000)
+	case c.consumer != nil:
+		metadata, err = c.consumer.GetMetadata(nil, false, 5000)
+	default:
+		return fmt.Errorf("neither producer nor consumer initialized")
 	}
-
-	// Get metadata to check connection
-	metadata, err := c.producer.GetMetadata(nil, false, 5000)
 	if err != nil {
 		return fmt.Errorf("failed to get metadata: %w", err)
 	}
@@ -233,6 +424,11 @@ func (c *Client) Ping(ctx context.Context) error {
 }
 
 func (c *Client) SendMessage(ctx context.Context, msg Message) error {
+	// Don't enqueue anything for a caller that has already given up
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -240,10 +436,116 @@ func (c *Client) SendMessage(ctx context.Context, msg Message) error {
 		return fmt.Errorf("client is closed")
 	}
 
+	if !c.produces() {
+		return c.roleError("producer")
+	}
 	if c.producer == nil {
 		return fmt.Errorf("producer not initialized")
 	}
 
+	kafkaMsg := c.toKafkaMessage(withTraceContext(ctx, withContextHeaders(ctx, msg)))
+	topic := *kafkaMsg.TopicPartition.Topic
+
+	// Send message. The channel is buffered so a delivery report arriving
+	// after we stop waiting (cancel/timeout) is parked in the buffer
+	// instead of blocking librdkafka's event goroutine, and the abandoned
+	// channel is garbage collected with it.
+	deliveryChan := make(chan kafka.Event, 1)
+	err := retry.Do(ctx, producePolicy, func() error {
+		return c.producer.Produce(kafkaMsg, deliveryChan)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to produce message: %w", err)
+	}
+
+	timeout := c.cfg.DeliveryTimeout
+	if timeout <= 0 {
+		timeout = defaultDeliveryTimeout
+	}
+
+	// Wait for delivery report with timeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case e := <-deliveryChan:
+		if m, ok := e.(*kafka.Message); ok {
+			if m.TopicPartition.Error != nil {
+				return fmt.Errorf("message delivery failed: %w", m.TopicPartition.Error)
+			}
+			c.logDelivered(topic, m)
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ErrDeliveryTimeout
+	}
+
+	return nil
+}
+
+// deliveryCallback is attached to async produces as the message opaque and
+// called by handleDeliveryReports with the delivery result.
+type deliveryCallback func(error)
+
+// SendMessageAsync enqueues msg without waiting for delivery. cb, if not
+// nil, is called with nil once the broker acknowledges the message or with
+// the delivery error. It runs on the delivery report goroutine and must
+// not block. If msg cannot be enqueued at all (client closed, no producer,
+// local queue still full after retrying) cb is called with the error
+// before SendMessageAsync returns.
+func (c *Client) SendMessageAsync(msg Message, cb func(error)) {
+	if cb == nil {
+		cb = func(error) {}
+	}
+	if err := c.produceAsync(msg, deliveryCallback(cb)); err != nil {
+		cb(err)
+	}
+}
+
+func (c *Client) produceAsync(msg Message, cb deliveryCallback) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return fmt.Errorf("client is closed")
+	}
+	if !c.produces() {
+		return c.roleError("producer")
+	}
+	if c.producer == nil {
+		return fmt.Errorf("producer not initialized")
+	}
+
+	kafkaMsg := c.toKafkaMessage(msg)
+	kafkaMsg.Opaque = cb
+
+	// A nil delivery channel routes the report to the producer's events
+	// channel, read by handleDeliveryReports
+	err := retry.Do(context.Background(), producePolicy, func() error {
+		return c.producer.Produce(kafkaMsg, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to produce message: %w", err)
+	}
+	return nil
+}
+
+// logDelivered records a successful produce with the sizes needed to
+// diagnose large messages and key distribution.
+func (c *Client) logDelivered(topic string, m *kafka.Message) {
+	c.logger.Debug("message sent successfully",
+		"topic", topic,
+		"partition", m.TopicPartition.Partition,
+		"offset", m.TopicPartition.Offset,
+		"key_length", len(m.Key),
+		"value_bytes", len(m.Value),
+		"header_count", len(m.Headers))
+}
+
+// toKafkaMessage converts msg into a producer message, defaulting the topic
+// to the configured one.
+func (c *Client) toKafkaMessage(msg Message) *kafka.Message {
 	topic := msg.Topic
 	if topic == "" {
 		topic = c.cfg.Topic
@@ -255,6 +557,11 @@ func (c *Client) SendMessage(ctx context.Context, msg Message) error {
 		Value:          msg.Value,
 	}
 
+	if !msg.Timestamp.IsZero() {
+		kafkaMsg.Timestamp = msg.Timestamp
+		kafkaMsg.TimestampType = kafka.TimestampCreateTime
+	}
+
 	// Add headers if provided
 	if msg.Headers != nil {
 		kafkaMsg.Headers = make([]kafka.Header, 0, len(msg.Headers))
@@ -266,76 +573,115 @@ func (c *Client) SendMessage(ctx context.Context, msg Message) error {
 		}
 	}
 
-	// Send message
-	deliveryChan := make(chan kafka.Event)
-	err := c.producer.Produce(kafkaMsg, deliveryChan)
-	if err != nil {
-		return fmt.Errorf("failed to produce message: %w", err)
+	return kafkaMsg
+}
+
+func (c *Client) SendAvroMessage(ctx context.Context, topic string, key []byte, value interface{}, subject string) error {
+	if c.avroSerializer == nil {
+		return fmt.Errorf("avro serializer not initialized")
 	}
 
-	// Wait for delivery report with timeout
-	select {
-	case e := <-deliveryChan:
-		if m, ok := e.(*kafka.Message); ok {
-			if m.TopicPartition.Error != nil {
-				return fmt.Errorf("message delivery failed: %w", m.TopicPartition.Error)
-			}
-			c.logger.Debug("message sent successfully",
-				"topic", topic,
-				"partition", m.TopicPartition.Partition,
-				"offset", m.TopicPartition.Offset)
-		}
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(30 * time.Second):
-		return fmt.Errorf("message delivery timeout")
+	serializedValue, err := serializeAvro(ctx, c.avroSerializer, registryPolicy, subject, value)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return c.SendMessage(ctx, Message{
+		Topic: topic,
+		Key:   key,
+		Value: serializedValue,
+	})
 }
 
-func (c *Client) SendAvroMessage(ctx context.Context, topic string, key []byte, value interface{}, subject string) error {
-	if c.avroSerializer == nil {
+// SendAvroMessageWithKey serializes both key and value with Avro, as
+// needed for compacted topics with structured keys.
+func (c *Client) SendAvroMessageWithKey(ctx context.Context, topic string, key interface{}, value interface{}, keySubject, valueSubject string) error {
+	if c.avroKeySerializer == nil || c.avroSerializer == nil {
 		return fmt.Errorf("avro serializer not initialized")
 	}
 
-	serializedValue, err := c.avroSerializer.Serialize(subject, value)
+	serializedKey, serializedValue, err := serializeAvroKeyValue(ctx, c.avroKeySerializer, c.avroSerializer, key, value, keySubject, valueSubject)
 	if err != nil {
-		return fmt.Errorf("failed to serialize avro message: %w", err)
+		return err
 	}
 
 	return c.SendMessage(ctx, Message{
 		Topic: topic,
-		Key:   key,
+		Key:   serializedKey,
 		Value: serializedValue,
 	})
 }
 
+// ConsumeMessages consumes the configured topic until ctx is cancelled.
 func (c *Client) ConsumeMessages(ctx context.Context, handler MessageHandler) error {
+	return c.ConsumeTopics(ctx, []string{c.cfg.Topic}, handler)
+}
+
+// ConsumeTopics subscribes to topics and passes each message to handler
+// until ctx is cancelled. Message.Topic tells handlers which topic a
+// message came from, and Message.Context carries its consume span.
+func (c *Client) ConsumeTopics(ctx context.Context, topics []string, handler MessageHandler) error {
 	c.mu.RLock()
 	consumer := c.consumer
-	topic := c.cfg.Topic
 	c.mu.RUnlock()
 
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics to consume")
+	}
+	if !c.consumes() {
+		return c.roleError("consumer")
+	}
 	if consumer == nil {
 		return fmt.Errorf("consumer not initialized")
 	}
 
-	// Subscribe to topic
-	err := consumer.SubscribeTopics([]string{topic}, nil)
+	ctx, done, err := c.startConsuming(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	// Commit to Kafka unless an external store is configured, in which
+	// case assigned partitions seek to its offsets
+	var store OffsetStore = kafkaOffsetStore{consumer: consumer}
+	var rebalance kafka.RebalanceCb
+	if c.offsetStore != nil {
+		store = c.offsetStore
+		rebalance = c.rebalanceCallback(ctx, store)
+	}
+
+	err = consumer.SubscribeTopics(topics, rebalance)
 	if err != nil {
-		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
+		return fmt.Errorf("failed to subscribe to topics %v: %w", topics, err)
+	}
+
+	c.logger.Info("started consuming messages", "topics", topics, "group_id", c.cfg.GroupID,
+		"concurrency", max(c.cfg.ConsumerConcurrency, 1))
+
+	// Batch commits only when configured; otherwise commit per message
+	var batcher *offsetBatcher
+	if c.cfg.CommitBatchSize > 1 || c.cfg.CommitInterval > 0 {
+		batcher = newOffsetBatcher(c.cfg.CommitBatchSize, c.cfg.CommitInterval)
 	}
 
-	c.logger.Info("started consuming messages", "topic", topic, "group_id", c.cfg.GroupID)
+	if c.cfg.ConsumerConcurrency > 1 {
+		return c.consumeConcurrently(ctx, consumer, store, batcher, handler)
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
+			if batcher != nil {
+				c.commitOffsets(ctx, store, batcher.take())
+			}
 			c.logger.Info("stopping message consumption")
 			return ctx.Err()
 		default:
-			msg, err := consumer.ReadMessage(1000) // 1 second timeout
+			if batcher != nil && batcher.due() {
+				c.commitOffsets(ctx, store, batcher.take())
+			}
+
+			msg, err := c.readMessage(consumer, time.Second)
 			if err != nil {
 				if kafkaErr, ok := err.(kafka.Error); ok && kafkaErr.Code() == kafka.ErrTimedOut {
 					continue // Timeout is expected, continue polling
@@ -344,41 +690,201 @@ func (c *Client) ConsumeMessages(ctx context.Context, handler MessageHandler) er
 				continue
 			}
 
-			// Convert kafka message to our Message type
-			ourMsg := Message{
-				Topic: *msg.TopicPartition.Topic,
-				Key:   msg.Key,
-				Value: msg.Value,
-			}
-
-			// Add headers if present
-			if len(msg.Headers) > 0 {
-				ourMsg.Headers = make(map[string][]byte)
-				for _, header := range msg.Headers {
-					ourMsg.Headers[header.Key] = header.Value
+			commit, err := c.processMessage(ctx, handler, msg)
+			if err != nil {
+				if batcher != nil {
+					c.commitOffsets(ctx, store, batcher.take())
 				}
+				c.logger.Info("stopping message consumption")
+				return err
 			}
-
-			// Process message
-			if err := handler(ourMsg); err != nil {
-				c.logger.Error("message handler failed",
-					"topic", *msg.TopicPartition.Topic,
-					"partition", msg.TopicPartition.Partition,
-					"offset", msg.TopicPartition.Offset,
-					"error", err)
-				continue
+			if commit {
+				c.commitProcessed(ctx, store, batcher, msg.TopicPartition)
+			} else {
+				c.redeliver(consumer, msg.TopicPartition)
 			}
+		}
+	}
+}
 
-			// Commit message
-			if _, err := consumer.CommitMessage(msg); err != nil {
-				c.logger.Error("failed to commit message",
-					"topic", *msg.TopicPartition.Topic,
-					"partition", msg.TopicPartition.Partition,
-					"offset", msg.TopicPartition.Offset,
-					"error", err)
-			}
+// startConsuming registers a consume loop so Close can stop it and wait
+// for it. The returned context is cancelled by Close; done must be called
+// when the loop exits.
+func (c *Client) startConsuming(ctx context.Context) (context.Context, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, nil, fmt.Errorf("client is closed")
+	}
+	if c.consumeDone != nil {
+		return nil, nil, fmt.Errorf("consumer is already consuming")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	exited := make(chan struct{})
+	c.stopConsume, c.consumeDone = cancel, exited
+
+	return ctx, func() {
+		cancel()
+		c.mu.Lock()
+		c.stopConsume, c.consumeDone = nil, nil
+		c.mu.Unlock()
+		close(exited)
+	}, nil
+}
+
+// toMessage converts a consumed Kafka message to a Message.
+func toMessage(msg *kafka.Message) Message {
+	m := Message{
+		Topic: *msg.TopicPartition.Topic,
+		Key:   msg.Key,
+		Value: msg.Value,
+	}
+
+	if len(msg.Headers) > 0 {
+		m.Headers = make(map[string][]byte)
+		for _, header := range msg.Headers {
+			m.Headers[header.Key] = header.Value
 		}
 	}
+	return m
+}
+
+// processMessage handles msg with retries, letting the current attempt
+// finish within the grace period if shutdown starts mid-handler, and then
+// applies the failure policy. It reports whether msg's offset may be
+// committed; false means msg could not be dead-lettered and must be read
+// again with redeliver. It returns ctx's error when shutdown interrupted
+// handling and msg must be left uncommitted.
+func (c *Client) processMessage(ctx context.Context, handler MessageHandler, msg *kafka.Message) (bool, error) {
+	ourMsg := toMessage(msg)
+	tp := msg.TopicPartition
+
+	ctx, span := c.startConsumeSpan(ctx, msg)
+	var err error
+	defer func() { endConsumeSpan(span, err) }()
+	ourMsg.ctx = context.WithoutCancel(ctx)
+
+	err = runWithGrace(ctx, c.cfg.ShutdownGrace, func() error {
+		return c.handleWithRetry(ctx, handler, ourMsg, tp)
+	})
+	if errors.Is(err, errGraceExpired) {
+		c.logger.Warn("abandoning in-flight message after shutdown grace period",
+			"topic", *tp.Topic,
+			"partition", tp.Partition,
+			"offset", tp.Offset,
+			"grace", c.cfg.ShutdownGrace)
+		return false, ctx.Err()
+	}
+	if err != nil && ctx.Err() != nil {
+		// Shutdown interrupted the retries; leave the message uncommitted
+		// so it is redelivered
+		return false, ctx.Err()
+	}
+	if err != nil {
+		return c.handleFailure(ctx, c.sendDeadLetter, ourMsg, tp, err), nil
+	}
+	return true, nil
+}
+
+// redeliver seeks consumer back to tp so the message is read again rather
+// than passed over by the commit of a later message.
+func (c *Client) redeliver(consumer *kafka.Consumer, tp kafka.TopicPartition) {
+	c.logger.Warn("redelivering message after failed dead-letter",
+		"topic", *tp.Topic,
+		"partition", tp.Partition,
+		"offset", tp.Offset)
+	if err := consumer.Seek(tp, 0); err != nil {
+		c.logger.Error("failed to seek back to message",
+			"topic", *tp.Topic,
+			"partition", tp.Partition,
+			"offset", tp.Offset,
+			"error", err)
+	}
+}
+
+// commitProcessed records tp as handled, committing its next offset now or
+// adding it to batcher.
+func (c *Client) commitProcessed(ctx context.Context, store OffsetStore, batcher *offsetBatcher, tp kafka.TopicPartition) {
+	if batcher != nil {
+		batcher.add(tp)
+		return
+	}
+
+	next := TopicOffset{
+		Topic:     *tp.Topic,
+		Partition: tp.Partition,
+		Offset:    int64(tp.Offset) + 1,
+	}
+	if err := store.Save(context.WithoutCancel(ctx), []TopicOffset{next}); err != nil {
+		c.logger.Error("failed to commit message",
+			"topic", *tp.Topic,
+			"partition", tp.Partition,
+			"offset", tp.Offset,
+			"error", err)
+	}
+}
+
+// callHandler runs handler, converting a panic into an error so one bad
+// message cannot kill the consume loop.
+func (c *Client) callHandler(handler MessageHandler, msg Message, tp kafka.TopicPartition) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			c.logger.Error("message handler panicked",
+				"topic", msg.Topic,
+				"partition", tp.Partition,
+				"offset", tp.Offset,
+				"panic", rec,
+				"stack", string(debug.Stack()))
+			err = fmt.Errorf("message handler panicked: %v", rec)
+		}
+	}()
+
+	return handler(msg)
+}
+
+// commitOffsets saves offsets to store. It still saves after ctx is
+// cancelled so progress made before shutdown is kept.
+func (c *Client) commitOffsets(ctx context.Context, store OffsetStore, offsets []kafka.TopicPartition) {
+	if len(offsets) == 0 {
+		return
+	}
+
+	if err := store.Save(context.WithoutCancel(ctx), toTopicOffsets(offsets)); err != nil {
+		c.logger.Error("failed to commit offsets", "partitions", len(offsets), "error", err)
+		return
+	}
+
+	c.logger.Debug("offsets committed", "partitions", len(offsets))
+}
+
+// Assignment returns the partitions currently assigned to the consumer as
+// "topic-partition" strings.
+func (c *Client) Assignment() ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, fmt.Errorf("client is closed")
+	}
+	if !c.consumes() {
+		return nil, c.roleError("consumer")
+	}
+	if c.consumer == nil {
+		return nil, fmt.Errorf("consumer not initialized")
+	}
+
+	partitions, err := c.consumer.Assignment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+
+	assigned := make([]string, 0, len(partitions))
+	for _, tp := range partitions {
+		assigned = append(assigned, fmt.Sprintf("%s-%d", *tp.Topic, tp.Partition))
+	}
+	return assigned, nil
 }
 
 func (c *Client) GetSchemaRegistry() schemaregistry.Client {
@@ -392,3 +898,11 @@ func (c *Client) GetAvroSerializer() *avro.GenericSerializer {
 func (c *Client) GetAvroDeserializer() *avro.GenericDeserializer {
 	return c.avroDeserializer
 }
+
+func (c *Client) GetAvroKeySerializer() *avro.GenericSerializer {
+	return c.avroKeySerializer
+}
+
+func (c *Client) GetAvroKeyDeserializer() *avro.GenericDeserializer {
+	return c.avroKeyDeserializer
+}
@@ -1,12 +1,20 @@
 package kafka
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/testutil"
 )
 
 func TestNew_InvalidBrokers(t *testing.T) {
@@ -23,6 +31,8 @@ func TestNew_InvalidBrokers(t *testing.T) {
 		URL: "", // Skip schema registry for this test
 	}
 
+	testutil.CheckGoroutines(t)
+
 	// This should not fail immediately as Confluent's client doesn't validate brokers on creation
 	client, err := New(kafkaCfg, srCfg, logger)
 	if err != nil {
@@ -30,7 +40,7 @@ func TestNew_InvalidBrokers(t *testing.T) {
 	}
 
 	if client != nil {
-		defer client.Close()
+		testutil.CloseAll(t, client)
 
 		// However, Ping should fail
 		ctx := context.Background()
@@ -108,6 +118,218 @@ func TestClient_ClosedOperations(t *testing.T) {
 	if err := client.SendMessage(ctx, msg); err == nil {
 		t.Error("expected SendMessage() to fail on closed client")
 	}
+
+	var asyncErr error
+	client.SendMessageAsync(msg, func(err error) { asyncErr = err })
+	if asyncErr == nil {
+		t.Error("expected SendMessageAsync() callback to report closed client")
+	}
+}
+
+func TestClient_CloseFlushes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+
+	kafkaCfg := config.KafkaConfig{
+		Brokers:          []string{"127.0.0.1:1"},
+		Topic:            "test-topic",
+		SecurityProtocol: "PLAINTEXT",
+		Role:             RoleProducer,
+		FlushTimeout:     200 * time.Millisecond,
+	}
+
+	client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	// The broker is unreachable, so the message stays queued
+	client.SendMessageAsync(Message{Value: []byte("queued")}, func(error) {})
+
+	start := time.Now()
+	err = client.Close()
+	if err == nil || !strings.Contains(err.Error(), "1 messages not flushed") {
+		t.Errorf("Close() error = %v, want 1 unflushed message", err)
+	}
+	if elapsed := time.Since(start); elapsed < kafkaCfg.FlushTimeout {
+		t.Errorf("Close() returned after %v, want it to flush for %v", elapsed, kafkaCfg.FlushTimeout)
+	}
+	if !strings.Contains(buf.String(), "producer closed with unflushed messages") || !strings.Contains(buf.String(), "remaining=1") {
+		t.Errorf("expected unflushed count to be logged, got %q", buf.String())
+	}
+}
+
+func TestClient_SendMessageAsync(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	kafkaCfg := config.KafkaConfig{
+		Brokers:          []string{"localhost:9092"},
+		Topic:            "test-topic",
+		SecurityProtocol: "PLAINTEXT",
+		Role:             RoleProducer,
+	}
+
+	client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	const sends = 100
+	results := make(chan error, sends)
+	for i := 0; i < sends; i++ {
+		client.SendMessageAsync(Message{Value: []byte(fmt.Sprintf("message-%d", i))}, func(err error) {
+			results <- err
+		})
+	}
+
+	// No broker will acknowledge the messages; purging them makes
+	// librdkafka report each one through the delivery report goroutine
+	if err := client.producer.Purge(kafka.PurgeQueue | kafka.PurgeInFlight); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < sends; i++ {
+		select {
+		case err := <-results:
+			var kafkaErr kafka.Error
+			if !errors.As(err, &kafkaErr) || kafkaErr.Code() != kafka.ErrPurgeQueue {
+				t.Errorf("callback error = %v, want purge error", err)
+			}
+		case <-timeout:
+			t.Fatalf("only %d of %d callbacks fired", i, sends)
+		}
+	}
+}
+
+func TestClient_ConsumeTopics(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	kafkaCfg := config.KafkaConfig{
+		Brokers:          []string{"localhost:9092"},
+		Topic:            "events",
+		GroupID:          "test-group",
+		SecurityProtocol: "PLAINTEXT",
+	}
+	handler := func(Message) error { return nil }
+
+	tests := []struct {
+		name    string
+		consume func(c *Client, ctx context.Context) error
+		want    []string
+	}{
+		{
+			name: "configured topic",
+			consume: func(c *Client, ctx context.Context) error {
+				return c.ConsumeMessages(ctx, handler)
+			},
+			want: []string{"events"},
+		},
+		{
+			name: "topic list",
+			consume: func(c *Client, ctx context.Context) error {
+				return c.ConsumeTopics(ctx, []string{"events", "events.retry", "events.dlq"}, handler)
+			},
+			want: []string{"events", "events.dlq", "events.retry"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			// A cancelled context returns right after subscribing, which
+			// is local to the consumer and needs no broker
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := tt.consume(client, ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("consume error = %v, want context.Canceled", err)
+			}
+
+			got, err := client.consumer.Subscription()
+			if err != nil {
+				t.Fatalf("Subscription() error = %v", err)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Subscription() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+	if err := client.ConsumeTopics(context.Background(), nil, handler); err == nil {
+		t.Error("ConsumeTopics() with no topics expected error")
+	}
+}
+
+func TestClient_Roles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+	msg := Message{Key: []byte("k"), Value: []byte("v"), Topic: "test-topic"}
+
+	tests := []struct {
+		role         string
+		wantProducer bool
+		wantConsumer bool
+	}{
+		{role: RoleProducer, wantProducer: true},
+		{role: RoleConsumer, wantConsumer: true},
+		{role: RoleBoth, wantProducer: true, wantConsumer: true},
+		{role: "", wantProducer: true, wantConsumer: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("role "+tt.role, func(t *testing.T) {
+			kafkaCfg := config.KafkaConfig{
+				Brokers:          []string{"localhost:9092"},
+				Topic:            "test-topic",
+				GroupID:          "test-group",
+				SecurityProtocol: "PLAINTEXT",
+				Role:             tt.role,
+			}
+			client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			if got := client.producer != nil; got != tt.wantProducer {
+				t.Errorf("producer initialized = %v, want %v", got, tt.wantProducer)
+			}
+			if got := client.consumer != nil; got != tt.wantConsumer {
+				t.Errorf("consumer initialized = %v, want %v", got, tt.wantConsumer)
+			}
+
+			if !tt.wantProducer {
+				if err := client.SendMessage(ctx, msg); !errors.Is(err, ErrRoleNotConfigured) {
+					t.Errorf("SendMessage() error = %v, want ErrRoleNotConfigured", err)
+				}
+			}
+			if !tt.wantConsumer {
+				handler := func(Message) error { return nil }
+				if err := client.ConsumeMessages(ctx, handler); !errors.Is(err, ErrRoleNotConfigured) {
+					t.Errorf("ConsumeMessages() error = %v, want ErrRoleNotConfigured", err)
+				}
+				if _, err := client.Assignment(); !errors.Is(err, ErrRoleNotConfigured) {
+					t.Errorf("Assignment() error = %v, want ErrRoleNotConfigured", err)
+				}
+			}
+			if !tt.wantProducer || !tt.wantConsumer {
+				if err := client.SelfTest(ctx, "probe", time.Second); !errors.Is(err, ErrRoleNotConfigured) {
+					t.Errorf("SelfTest() error = %v, want ErrRoleNotConfigured", err)
+				}
+			}
+		})
+	}
 }
 
 func TestMessage_Headers(t *testing.T) {
@@ -263,3 +485,243 @@ func TestSchemaRegistryConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ToKafkaMessageTimestamp(t *testing.T) {
+	client := &Client{cfg: config.KafkaConfig{Topic: "test-topic"}}
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	kafkaMsg := client.toKafkaMessage(Message{
+		Key:       []byte("test-key"),
+		Value:     []byte("test-value"),
+		Timestamp: ts,
+	})
+
+	if !kafkaMsg.Timestamp.Equal(ts) {
+		t.Errorf("expected timestamp %v, got %v", ts, kafkaMsg.Timestamp)
+	}
+	if kafkaMsg.TimestampType != kafka.TimestampCreateTime {
+		t.Errorf("expected timestamp type %v, got %v", kafka.TimestampCreateTime, kafkaMsg.TimestampType)
+	}
+	if *kafkaMsg.TopicPartition.Topic != "test-topic" {
+		t.Errorf("expected default topic test-topic, got %s", *kafkaMsg.TopicPartition.Topic)
+	}
+
+	// Without an explicit timestamp the producer assigns it
+	kafkaMsg = client.toKafkaMessage(Message{Value: []byte("test-value")})
+	if !kafkaMsg.Timestamp.IsZero() {
+		t.Errorf("expected zero timestamp, got %v", kafkaMsg.Timestamp)
+	}
+	if kafkaMsg.TimestampType != kafka.TimestampNotAvailable {
+		t.Errorf("expected timestamp type %v, got %v", kafka.TimestampNotAvailable, kafkaMsg.TimestampType)
+	}
+}
+
+func TestClient_SendMessageContextCancellation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	kafkaCfg := config.KafkaConfig{
+		Brokers:          []string{"invalid:9999"},
+		Topic:            "test-topic",
+		GroupID:          "test-group",
+		SecurityProtocol: "PLAINTEXT",
+		FlushTimeout:     100 * time.Millisecond,
+	}
+
+	client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	msg := Message{
+		Key:   []byte("test-key"),
+		Value: []byte("test-value"),
+	}
+
+	t.Run("pre-cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := client.SendMessage(ctx, msg); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("cancelled during delivery wait", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		if err := client.SendMessage(ctx, msg); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("SendMessage() took %v after cancellation", elapsed)
+		}
+	})
+}
+
+func TestClient_SendMessageDeliveryTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	kafkaCfg := config.KafkaConfig{
+		Brokers:          []string{"127.0.0.1:1"},
+		Topic:            "test-topic",
+		SecurityProtocol: "PLAINTEXT",
+		Role:             RoleProducer,
+		DeliveryTimeout:  50 * time.Millisecond,
+		FlushTimeout:     time.Millisecond,
+	}
+
+	client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	err = client.SendMessage(context.Background(), Message{Value: []byte("undeliverable")})
+	if !errors.Is(err, ErrDeliveryTimeout) {
+		t.Errorf("SendMessage() error = %v, want ErrDeliveryTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SendMessage() took %v, want it bounded by the %v delivery timeout", elapsed, kafkaCfg.DeliveryTimeout)
+	}
+}
+
+// TestClient_SendMessageAbandonedDelivery gives up on many sends before
+// their delivery reports arrive. The late reports must not block the
+// producer's event goroutine or leak goroutines.
+func TestClient_SendMessageAbandonedDelivery(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	kafkaCfg := config.KafkaConfig{
+		Brokers:          []string{"localhost:9092"},
+		Topic:            "test-topic",
+		SecurityProtocol: "PLAINTEXT",
+		Role:             RoleProducer,
+		DeliveryTimeout:  time.Millisecond,
+	}
+
+	testutil.CheckGoroutines(t)
+
+	client, err := New(kafkaCfg, config.SchemaRegistryConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	for i := 0; i < 200; i++ {
+		err := client.SendMessage(context.Background(), Message{Value: []byte("abandoned")})
+		if !errors.Is(err, ErrDeliveryTimeout) {
+			t.Fatalf("SendMessage() error = %v, want ErrDeliveryTimeout", err)
+		}
+	}
+
+	// Deliver every abandoned report at once, then check reports still
+	// flow by waiting for one more
+	if err := client.producer.Purge(kafka.PurgeQueue | kafka.PurgeInFlight); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	delivered := make(chan error, 1)
+	client.SendMessageAsync(Message{Value: []byte("after")}, func(err error) { delivered <- err })
+	if err := client.producer.Purge(kafka.PurgeQueue | kafka.PurgeInFlight); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	select {
+	case <-delivered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("delivery reports blocked after abandoned sends")
+	}
+}
+
+func TestClient_CallHandlerRecoversPanic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	client := &Client{logger: slog.New(slog.NewJSONHandler(buf, nil))}
+
+	topic := "orders"
+	tp := kafka.TopicPartition{Topic: &topic, Partition: 3, Offset: 42}
+	msg := Message{Topic: topic, Value: []byte("boom")}
+
+	panicking := func(Message) error { panic("nil map write") }
+
+	// Run several messages as the consume loop would; the panic must not
+	// escape and later messages must still be handled
+	handled := 0
+	for i := 0; i < 3; i++ {
+		err := runWithGrace(context.Background(), 0, func() error {
+			return client.callHandler(panicking, msg, tp)
+		})
+		if err == nil || !strings.Contains(err.Error(), "nil map write") {
+			t.Fatalf("callHandler() error = %v, want panic converted to error", err)
+		}
+
+		if err := client.callHandler(func(Message) error { handled++; return nil }, msg, tp); err != nil {
+			t.Fatalf("callHandler() error = %v", err)
+		}
+	}
+
+	if handled != 3 {
+		t.Errorf("expected 3 messages handled after panics, got %d", handled)
+	}
+
+	logged := buf.String()
+	for _, want := range []string{`"msg":"message handler panicked"`, `"topic":"orders"`, `"partition":3`, `"offset":42`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected log to contain %s, got %s", want, logged)
+		}
+	}
+}
+
+func TestClient_LogDelivered(t *testing.T) {
+	buf := &bytes.Buffer{}
+	client := &Client{logger: slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	topic := "orders"
+	client.logDelivered(topic, &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 1, Offset: 7},
+		Key:            []byte("order-42"),
+		Value:          []byte(`{"id":42}`),
+		Headers: []kafka.Header{
+			{Key: "X-Request-ID", Value: []byte("req-1")},
+			{Key: "traceparent", Value: []byte("00-abc-def-01")},
+		},
+	})
+
+	logged := buf.String()
+	for _, want := range []string{
+		`"msg":"message sent successfully"`,
+		`"topic":"orders"`,
+		`"partition":1`,
+		`"key_length":8`,
+		`"value_bytes":9`,
+		`"header_count":2`,
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected debug log to contain %s, got %s", want, logged)
+		}
+	}
+}
+
+func TestIsQueueFull(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "queue full", err: kafka.NewError(kafka.ErrQueueFull, "queue full", false), want: true},
+		{name: "wrapped queue full", err: fmt.Errorf("produce: %w", kafka.NewError(kafka.ErrQueueFull, "queue full", false)), want: true},
+		{name: "other kafka error", err: kafka.NewError(kafka.ErrMsgSizeTooLarge, "too large", false), want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isQueueFull(tt.err); got != tt.want {
+				t.Errorf("isQueueFull() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
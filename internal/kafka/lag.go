@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// lagQueryTimeout bounds each broker query made by ConsumerLag when ctx
+// has no deadline.
+const lagQueryTimeout = 5 * time.Second
+
+// ConsumerLag returns, per assigned partition keyed "topic-partition", how
+// many messages the consumer group has not yet committed: the high
+// watermark minus the committed offset. Partitions without a committed
+// offset count from the low watermark, matching auto.offset.reset
+// earliest. With an external offset store the stored offsets are used.
+// The same lag is exported continuously as kafka_consumer_lag_messages
+// when librdkafka statistics are enabled.
+func (c *Client) ConsumerLag(ctx context.Context) (map[string]int64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, fmt.Errorf("client is closed")
+	}
+	if !c.consumes() {
+		return nil, c.roleError("consumer")
+	}
+	if c.consumer == nil {
+		return nil, fmt.Errorf("consumer not initialized")
+	}
+
+	timeoutMs := int(lagQueryTimeout.Milliseconds())
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutMs = max(int(time.Until(deadline).Milliseconds()), 1)
+	}
+
+	partitions, err := c.consumer.Assignment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+
+	committed, err := c.committedOffsets(ctx, partitions, timeoutMs)
+	if err != nil {
+		return nil, err
+	}
+
+	lag := make(map[string]int64, len(partitions))
+	for _, tp := range partitions {
+		low, high, err := c.consumer.QueryWatermarkOffsets(*tp.Topic, tp.Partition, timeoutMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query watermarks for %s-%d: %w", *tp.Topic, tp.Partition, err)
+		}
+
+		offset, ok := committed[partitionKey{topic: *tp.Topic, partition: tp.Partition}]
+		if !ok {
+			offset = int64(kafka.OffsetInvalid)
+		}
+		lag[fmt.Sprintf("%s-%d", *tp.Topic, tp.Partition)] = partitionLag(low, high, offset)
+	}
+	return lag, nil
+}
+
+// committedOffsets returns the committed next offset per partition, from
+// the external offset store when one is configured.
+func (c *Client) committedOffsets(ctx context.Context, partitions []kafka.TopicPartition, timeoutMs int) (map[partitionKey]int64, error) {
+	offsets := make(map[partitionKey]int64, len(partitions))
+
+	if c.offsetStore != nil {
+		loaded := make(map[string]bool)
+		for _, tp := range partitions {
+			if loaded[*tp.Topic] {
+				continue
+			}
+			loaded[*tp.Topic] = true
+
+			stored, err := c.offsetStore.Load(ctx, *tp.Topic)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load committed offsets: %w", err)
+			}
+			for partition, offset := range stored {
+				offsets[partitionKey{topic: *tp.Topic, partition: partition}] = offset
+			}
+		}
+		return offsets, nil
+	}
+
+	committed, err := c.consumer.Committed(partitions, timeoutMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get committed offsets: %w", err)
+	}
+	for _, tp := range committed {
+		offsets[partitionKey{topic: *tp.Topic, partition: tp.Partition}] = int64(tp.Offset)
+	}
+	return offsets, nil
+}
+
+// partitionLag is high minus committed, counting from low when nothing is
+// committed (a negative logical offset) or the committed offset has been
+// deleted by retention. It is never negative.
+func partitionLag(low, high, committed int64) int64 {
+	if committed < low {
+		committed = low
+	}
+	return max(high-committed, 0)
+}
@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/testutil"
+)
+
+func TestPartitionLag(t *testing.T) {
+	tests := []struct {
+		name      string
+		low       int64
+		high      int64
+		committed int64
+		want      int64
+	}{
+		{name: "behind", low: 0, high: 100, committed: 60, want: 40},
+		{name: "caught up", low: 0, high: 100, committed: 100, want: 0},
+		{name: "nothing committed", low: 20, high: 100, committed: int64(kafka.OffsetInvalid), want: 80},
+		{name: "committed offset deleted by retention", low: 50, high: 100, committed: 10, want: 50},
+		{name: "empty partition", low: 0, high: 0, committed: int64(kafka.OffsetInvalid), want: 0},
+		{name: "watermark behind commit", low: 0, high: 90, committed: 100, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := partitionLag(tt.low, tt.high, tt.committed); got != tt.want {
+				t.Errorf("partitionLag(%d, %d, %d) = %d, want %d", tt.low, tt.high, tt.committed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ConsumerLagNotInitialized(t *testing.T) {
+	client := &Client{}
+	if _, err := client.ConsumerLag(context.Background()); err == nil {
+		t.Error("expected ConsumerLag() to fail without a consumer")
+	}
+}
+
+func TestClient_ConsumerLag(t *testing.T) {
+	const topic = "lagging"
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to create mock cluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+	if err := cluster.CreateTopic(topic, 2, 1); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	client, err := New(config.KafkaConfig{
+		Brokers:          []string{cluster.BootstrapServers()},
+		Topic:            topic,
+		GroupID:          "lag-group",
+		SecurityProtocol: "PLAINTEXT",
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	topicName := topic
+	for i := 0; i < 10; i++ {
+		err := client.producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topicName, Partition: 0},
+			Value:          []byte("v"),
+		}, nil)
+		if err != nil {
+			t.Fatalf("Produce() error = %v", err)
+		}
+	}
+	if remaining := client.producer.Flush(10000); remaining != 0 {
+		t.Fatalf("%d messages not flushed", remaining)
+	}
+
+	if err := client.consumer.Assign([]kafka.TopicPartition{
+		testPartition(topic, 0, kafka.OffsetStored),
+		testPartition(topic, 1, kafka.OffsetStored),
+	}); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if _, err := client.consumer.CommitOffsets([]kafka.TopicPartition{testPartition(topic, 0, 4)}); err != nil {
+		t.Fatalf("CommitOffsets() error = %v", err)
+	}
+
+	lag, err := client.ConsumerLag(context.Background())
+	if err != nil {
+		t.Fatalf("ConsumerLag() error = %v", err)
+	}
+
+	want := map[string]int64{topic + "-0": 6, topic + "-1": 0}
+	if len(lag) != len(want) {
+		t.Fatalf("ConsumerLag() = %v, want %v", lag, want)
+	}
+	for key, w := range want {
+		if lag[key] != w {
+			t.Errorf("lag[%s] = %d, want %d", key, lag[key], w)
+		}
+	}
+}
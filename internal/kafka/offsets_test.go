@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// sqlOffsetDB adapts *sql.DB to OffsetDB.
+type sqlOffsetDB struct {
+	*sql.DB
+}
+
+func (d sqlOffsetDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.ExecContext(ctx, query, args...)
+}
+
+func (d sqlOffsetDB) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.QueryContext(ctx, query, args...)
+}
+
+func newMockOffsetStore(t *testing.T) (*DBOffsetStore, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewDBOffsetStore(sqlOffsetDB{conn}, "orders-consumer"), mock
+}
+
+func TestDBOffsetStore_Save(t *testing.T) {
+	store, mock := newMockOffsetStore(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`INSERT INTO kafka_offsets (group_id, topic, partition, next_offset) VALUES ($1, $2, $3, $4), ($5, $6, $7, $8) ON CONFLICT`)).
+		WithArgs("orders-consumer", "orders", int32(0), int64(42), "orders-consumer", "orders", int32(3), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := store.Save(context.Background(), []TopicOffset{
+		{Topic: "orders", Partition: 0, Offset: 42},
+		{Topic: "orders", Partition: 3, Offset: 7},
+	})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDBOffsetStore_SaveEmpty(t *testing.T) {
+	store, mock := newMockOffsetStore(t)
+
+	if err := store.Save(context.Background(), nil); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+}
+
+func TestDBOffsetStore_Load(t *testing.T) {
+	store, mock := newMockOffsetStore(t)
+
+	mock.ExpectQuery("SELECT partition, next_offset FROM kafka_offsets").
+		WithArgs("orders-consumer", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"partition", "next_offset"}).
+			AddRow(0, 42).
+			AddRow(3, 7))
+
+	got, err := store.Load(context.Background(), "orders")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[int32]int64{0: 42, 3: 7}
+	if len(got) != len(want) {
+		t.Fatalf("Load() = %v, want %v", got, want)
+	}
+	for partition, offset := range want {
+		if got[partition] != offset {
+			t.Errorf("Load()[%d] = %d, want %d", partition, got[partition], offset)
+		}
+	}
+}
+
+func TestApplyStoredOffsets(t *testing.T) {
+	topic := "orders"
+	partitions := []kafka.TopicPartition{
+		{Topic: &topic, Partition: 0, Offset: kafka.OffsetInvalid},
+		{Topic: &topic, Partition: 1, Offset: kafka.OffsetInvalid},
+	}
+
+	got := applyStoredOffsets(partitions, map[int32]int64{0: 42})
+
+	if got[0].Offset != 42 {
+		t.Errorf("partition 0 offset = %v, want 42", got[0].Offset)
+	}
+	if got[1].Offset != kafka.OffsetStored {
+		t.Errorf("partition 1 offset = %v, want stored", got[1].Offset)
+	}
+	if partitions[0].Offset != kafka.OffsetInvalid {
+		t.Error("applyStoredOffsets() modified its input")
+	}
+}
@@ -0,0 +1,245 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// workerQueueSize is how many messages may wait for each worker before the
+// poll loop blocks.
+const workerQueueSize = 16
+
+// offsetTracker follows the messages dispatched to workers per partition
+// so commits never pass a message that is still being handled. Messages
+// are dispatched in offset order, so each partition's pending messages
+// form a queue.
+type offsetTracker struct {
+	pending map[partitionKey][]trackedOffset
+	// rewound holds the offset each partition was sought back to, until
+	// the message there is dispatched again
+	rewound map[partitionKey]kafka.Offset
+}
+
+type trackedOffset struct {
+	offset kafka.Offset
+	done   bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending: make(map[partitionKey][]trackedOffset),
+		rewound: make(map[partitionKey]kafka.Offset),
+	}
+}
+
+// dispatched records that tp was handed to a worker.
+func (t *offsetTracker) dispatched(tp kafka.TopicPartition) {
+	key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+	if offset, ok := t.rewound[key]; ok && tp.Offset == offset {
+		delete(t.rewound, key)
+	}
+	t.pending[key] = append(t.pending[key], trackedOffset{offset: tp.Offset})
+}
+
+// stale reports whether tp was read before its partition was sought back
+// to an offset at or below it and has not been dispatched again since.
+// Its outcome is ignored; the message is read again.
+func (t *offsetTracker) stale(tp kafka.TopicPartition) bool {
+	offset, ok := t.rewound[partitionKey{topic: *tp.Topic, partition: tp.Partition}]
+	return ok && tp.Offset >= offset
+}
+
+// rewind forgets tp and every later message of its partition, which are
+// read again once the consumer seeks back to tp. It reports false when
+// the partition is already being reread from tp or earlier.
+func (t *offsetTracker) rewind(tp kafka.TopicPartition) bool {
+	if t.stale(tp) {
+		return false
+	}
+
+	key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+	queue := t.pending[key]
+	for i := range queue {
+		if queue[i].offset >= tp.Offset {
+			queue = queue[:i]
+			break
+		}
+	}
+	if len(queue) == 0 {
+		delete(t.pending, key)
+	} else {
+		t.pending[key] = queue
+	}
+	t.rewound[key] = tp.Offset
+	return true
+}
+
+// handled marks tp as handled. When that completes a prefix of its
+// partition's pending messages it returns the last message of the prefix,
+// whose next offset is the lowest uncommitted one, and true.
+func (t *offsetTracker) handled(tp kafka.TopicPartition) (kafka.TopicPartition, bool) {
+	if t.stale(tp) {
+		return kafka.TopicPartition{}, false
+	}
+
+	key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+	queue := t.pending[key]
+	for i := range queue {
+		if queue[i].offset == tp.Offset {
+			queue[i].done = true
+			break
+		}
+	}
+
+	n := 0
+	for n < len(queue) && queue[n].done {
+		n++
+	}
+	if n == 0 {
+		return kafka.TopicPartition{}, false
+	}
+
+	last := kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition, Offset: queue[n-1].offset}
+	if n == len(queue) {
+		delete(t.pending, key)
+	} else {
+		t.pending[key] = queue[n:]
+	}
+	return last, true
+}
+
+// handledMessage reports the outcome of a message handled by a worker.
+type handledMessage struct {
+	tp     kafka.TopicPartition
+	commit bool
+}
+
+// consumeConcurrently runs the poll loop with messages handled by a pool
+// of ConsumerConcurrency workers. Each partition always goes to the same
+// worker, so messages within a partition are handled in order while
+// partitions proceed in parallel. Offsets are committed from this
+// goroutine once every earlier message in the partition is handled.
+func (c *Client) consumeConcurrently(ctx context.Context, consumer *kafka.Consumer, store OffsetStore, batcher *offsetBatcher, handler MessageHandler) error {
+	workers := c.cfg.ConsumerConcurrency
+	queues := make([]chan *kafka.Message, workers)
+	results := make(chan handledMessage, workers*workerQueueSize)
+	tracker := newOffsetTracker()
+
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *kafka.Message, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan *kafka.Message) {
+			defer wg.Done()
+			for msg := range queue {
+				// Messages still queued at shutdown are left uncommitted
+				if ctx.Err() != nil {
+					continue
+				}
+				commit, err := c.processMessage(ctx, handler, msg)
+				if err != nil {
+					continue
+				}
+				results <- handledMessage{tp: msg.TopicPartition, commit: commit}
+			}
+		}(queues[i])
+	}
+
+	record := func(r handledMessage) {
+		// A message that could not be dead-lettered is read again, along
+		// with the rest of its partition, before anything past it commits
+		if !r.commit {
+			if tracker.rewind(r.tp) {
+				c.redeliver(consumer, r.tp)
+			}
+			return
+		}
+		if last, ok := tracker.handled(r.tp); ok {
+			c.commitProcessed(ctx, store, batcher, last)
+		}
+	}
+
+	// stop closes the queues and records results until every worker exits
+	stop := func() error {
+		for _, queue := range queues {
+			close(queue)
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		for {
+			select {
+			case r := <-results:
+				record(r)
+			case <-done:
+				for {
+					select {
+					case r := <-results:
+						record(r)
+					default:
+						if batcher != nil {
+							c.commitOffsets(ctx, store, batcher.take())
+						}
+						c.logger.Info("stopping message consumption")
+						return ctx.Err()
+					}
+				}
+			}
+		}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return stop()
+		}
+
+		// Record finished messages before polling again
+		for drained := false; !drained; {
+			select {
+			case r := <-results:
+				record(r)
+			default:
+				drained = true
+			}
+		}
+		if batcher != nil && batcher.due() {
+			c.commitOffsets(ctx, store, batcher.take())
+		}
+
+		msg, err := c.readMessage(consumer, 100*time.Millisecond)
+		if err != nil {
+			if kafkaErr, ok := err.(kafka.Error); !ok || kafkaErr.Code() != kafka.ErrTimedOut {
+				c.logger.Error("failed to read message", "error", err)
+			}
+			continue
+		}
+
+		tracker.dispatched(msg.TopicPartition)
+		queue := queues[workerFor(msg.TopicPartition, workers)]
+		for sent := false; !sent; {
+			// Keep recording results while the worker's queue is full so
+			// workers blocked on results cannot deadlock the dispatch
+			select {
+			case queue <- msg:
+				sent = true
+			case r := <-results:
+				record(r)
+			}
+		}
+	}
+}
+
+// workerFor picks the worker for tp's partition.
+func workerFor(tp kafka.TopicPartition, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(*tp.Topic))
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(tp.Partition)))
+	return int(h.Sum32() % uint32(workers))
+}
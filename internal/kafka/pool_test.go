@@ -0,0 +1,216 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/testutil"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	for _, offset := range []kafka.Offset{10, 11, 12} {
+		tracker.dispatched(testPartition("orders", 0, offset))
+	}
+	tracker.dispatched(testPartition("orders", 1, 5))
+
+	// A later message finishing first cannot be committed past an earlier
+	// one still in flight
+	if _, ok := tracker.handled(testPartition("orders", 0, 11)); ok {
+		t.Error("handled(11) allowed a commit while 10 is in flight")
+	}
+
+	last, ok := tracker.handled(testPartition("orders", 0, 10))
+	if !ok || last.Offset != 11 {
+		t.Errorf("handled(10) = %v, %v, want offset 11 committable", last.Offset, ok)
+	}
+
+	last, ok = tracker.handled(testPartition("orders", 1, 5))
+	if !ok || last.Partition != 1 || last.Offset != 5 {
+		t.Errorf("handled(p1 5) = %v, %v, want partition 1 offset 5", last, ok)
+	}
+
+	last, ok = tracker.handled(testPartition("orders", 0, 12))
+	if !ok || last.Offset != 12 {
+		t.Errorf("handled(12) = %v, %v, want offset 12 committable", last.Offset, ok)
+	}
+	if len(tracker.pending) != 0 {
+		t.Errorf("expected no pending partitions, got %v", tracker.pending)
+	}
+}
+
+func TestOffsetTracker_Rewind(t *testing.T) {
+	tracker := newOffsetTracker()
+	for _, offset := range []kafka.Offset{10, 11, 12} {
+		tracker.dispatched(testPartition("orders", 0, offset))
+	}
+
+	if _, ok := tracker.handled(testPartition("orders", 0, 10)); !ok {
+		t.Fatal("handled(10) did not allow a commit")
+	}
+	if !tracker.rewind(testPartition("orders", 0, 11)) {
+		t.Fatal("rewind(11) = false, want true")
+	}
+
+	// Outcomes of messages read before the seek are ignored
+	if tracker.rewind(testPartition("orders", 0, 12)) {
+		t.Error("rewind(12) = true while already rereading from 11")
+	}
+	if _, ok := tracker.handled(testPartition("orders", 0, 12)); ok {
+		t.Error("handled(12) allowed a commit past the rewound message")
+	}
+
+	// Once reread, the partition commits as usual
+	tracker.dispatched(testPartition("orders", 0, 11))
+	tracker.dispatched(testPartition("orders", 0, 12))
+	tracker.handled(testPartition("orders", 0, 11))
+	last, ok := tracker.handled(testPartition("orders", 0, 12))
+	if !ok || last.Offset != 12 {
+		t.Errorf("handled(12) = %v, %v, want offset 12 committable", last.Offset, ok)
+	}
+}
+
+func TestClient_ConsumeConcurrently(t *testing.T) {
+	const topic = "concurrent"
+	const partitions = 4
+	const perPartition = 20
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to create mock cluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+	if err := cluster.CreateTopic(topic, partitions, 1); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	client, err := New(config.KafkaConfig{
+		Brokers:             []string{cluster.BootstrapServers()},
+		Topic:               topic,
+		GroupID:             "concurrent-group",
+		SecurityProtocol:    "PLAINTEXT",
+		ConsumerConcurrency: partitions,
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	workers := make(map[int]bool)
+	for p := int32(0); p < partitions; p++ {
+		workers[workerFor(testPartition(topic, p, 0), partitions)] = true
+	}
+	if len(workers) < 2 {
+		t.Fatal("test partitions all map to one worker")
+	}
+
+	// Produce to explicit partitions; each value records its partition
+	// and sequence number
+	topicName := topic
+	for p := int32(0); p < partitions; p++ {
+		for i := 0; i < perPartition; i++ {
+			err := client.producer.Produce(&kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &topicName, Partition: p},
+				Value:          []byte(fmt.Sprintf("%d:%d", p, i)),
+			}, nil)
+			if err != nil {
+				t.Fatalf("Produce() error = %v", err)
+			}
+		}
+	}
+	if remaining := client.producer.Flush(10000); remaining != 0 {
+		t.Fatalf("%d messages not flushed", remaining)
+	}
+
+	var (
+		mu       sync.Mutex
+		seen     = make(map[int][]int)
+		total    int
+		active   atomic.Int32
+		peak     atomic.Int32
+		finished = make(chan struct{})
+	)
+	handler := func(msg Message) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var partition, seq int
+		if _, err := fmt.Sscanf(string(msg.Value), "%d:%d", &partition, &seq); err != nil {
+			t.Errorf("unexpected value %q", msg.Value)
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		seen[partition] = append(seen[partition], seq)
+		total++
+		if total == partitions*perPartition {
+			close(finished)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- client.ConsumeMessages(ctx, handler)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(30 * time.Second):
+		mu.Lock()
+		defer mu.Unlock()
+		t.Fatalf("handled %d of %d messages", total, partitions*perPartition)
+	}
+	cancel()
+	if err := <-consumed; err != context.Canceled {
+		t.Errorf("ConsumeMessages() error = %v, want context.Canceled", err)
+	}
+
+	if peak.Load() < 2 {
+		t.Errorf("expected messages handled concurrently, peak concurrency %d", peak.Load())
+	}
+	for p := 0; p < partitions; p++ {
+		got := seen[p]
+		if len(got) != perPartition {
+			t.Errorf("partition %d handled %d messages, want %d", p, len(got), perPartition)
+			continue
+		}
+		for i, seq := range got {
+			if seq != i {
+				t.Errorf("partition %d handled out of order: %v", p, got)
+				break
+			}
+		}
+	}
+
+	// Every handled message was committed
+	committed, err := client.consumer.Committed([]kafka.TopicPartition{
+		testPartition(topic, 0, 0), testPartition(topic, 1, 0),
+		testPartition(topic, 2, 0), testPartition(topic, 3, 0),
+	}, 5000)
+	if err != nil {
+		t.Fatalf("Committed() error = %v", err)
+	}
+	for _, tp := range committed {
+		if tp.Offset != perPartition {
+			t.Errorf("partition %d committed offset %v, want %d", tp.Partition, tp.Offset, perPartition)
+		}
+	}
+}
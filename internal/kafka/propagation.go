@@ -0,0 +1,48 @@
+package kafka
+
+import "context"
+
+type headersKey struct{}
+
+// ContextWithHeaders returns a context carrying headers that SendMessage
+// adds to every message produced with it. This keeps correlation data
+// (request ID, trace context, tenant) attached across the async boundary.
+func ContextWithHeaders(ctx context.Context, headers map[string][]byte) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+
+	merged := make(map[string][]byte, len(headers))
+	for k, v := range HeadersFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromContext returns the headers stored by ContextWithHeaders.
+func HeadersFromContext(ctx context.Context) map[string][]byte {
+	headers, _ := ctx.Value(headersKey{}).(map[string][]byte)
+	return headers
+}
+
+// withContextHeaders adds headers carried by ctx to msg. Headers already
+// set on the message take precedence.
+func withContextHeaders(ctx context.Context, msg Message) Message {
+	propagated := HeadersFromContext(ctx)
+	if len(propagated) == 0 {
+		return msg
+	}
+
+	headers := make(map[string][]byte, len(propagated)+len(msg.Headers))
+	for k, v := range propagated {
+		headers[k] = v
+	}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	msg.Headers = headers
+	return msg
+}
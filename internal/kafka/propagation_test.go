@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithContextHeaders(t *testing.T) {
+	ctx := ContextWithHeaders(context.Background(), map[string][]byte{
+		"X-Request-ID": []byte("req-1"),
+		"X-Tenant-ID":  []byte("tenant-a"),
+	})
+
+	msg := withContextHeaders(ctx, Message{
+		Headers: map[string][]byte{
+			"X-Tenant-ID":  []byte("tenant-b"),
+			"content-type": []byte("application/json"),
+		},
+	})
+
+	want := map[string]string{
+		"X-Request-ID": "req-1",
+		"X-Tenant-ID":  "tenant-b", // message header wins
+		"content-type": "application/json",
+	}
+
+	if len(msg.Headers) != len(want) {
+		t.Fatalf("expected %d headers, got %d: %v", len(want), len(msg.Headers), msg.Headers)
+	}
+	for k, v := range want {
+		if string(msg.Headers[k]) != v {
+			t.Errorf("header %s = %q, want %q", k, msg.Headers[k], v)
+		}
+	}
+}
+
+func TestWithContextHeaders_NoHeaders(t *testing.T) {
+	msg := withContextHeaders(context.Background(), Message{Value: []byte("test-value")})
+	if msg.Headers != nil {
+		t.Errorf("expected no headers, got %v", msg.Headers)
+	}
+}
+
+func TestContextWithHeaders_Merges(t *testing.T) {
+	ctx := ContextWithHeaders(context.Background(), map[string][]byte{"a": []byte("1")})
+	ctx = ContextWithHeaders(ctx, map[string][]byte{"b": []byte("2")})
+
+	headers := HeadersFromContext(ctx)
+	if string(headers["a"]) != "1" || string(headers["b"]) != "2" {
+		t.Errorf("expected merged headers a=1 b=2, got %v", headers)
+	}
+}
@@ -0,0 +1,280 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry"
+	"github.com/sksmith/go-base-ms/internal/retry"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Serialization formats for schema-registry encoded messages.
+const (
+	SerializationAvro     = "avro"
+	SerializationProtobuf = "protobuf"
+)
+
+// wireMagic starts every Confluent Schema Registry framed payload.
+const wireMagic = 0
+
+// schemaTypeProtobuf is the registry's type for .proto schemas.
+const schemaTypeProtobuf = "PROTOBUF"
+
+// ProtobufSerializer encodes protobuf messages in the Confluent wire
+// format: a magic byte, the schema ID, the message's index path within its
+// .proto file, then the protobuf payload.
+//
+// The schema ID is the latest one registered under the subject, checked to
+// declare the message and then cached for the life of the serializer.
+// Schemas are not auto-registered, since rendering .proto sources from a
+// descriptor needs a library this module does not depend on; register them
+// from the .proto sources when they are published. The check is textual:
+// it matches the package and message names but does not compare fields.
+type ProtobufSerializer struct {
+	client schemaregistry.Client
+
+	mu  sync.Mutex
+	ids map[subjectMessage]int
+}
+
+// subjectMessage keys the schema IDs validated for a message type.
+type subjectMessage struct {
+	subject string
+	message protoreflect.FullName
+}
+
+func NewProtobufSerializer(client schemaregistry.Client) *ProtobufSerializer {
+	return &ProtobufSerializer{client: client, ids: make(map[subjectMessage]int)}
+}
+
+func (s *ProtobufSerializer) Serialize(subject string, msg proto.Message) ([]byte, error) {
+	id, err := s.schemaID(subject, msg.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+
+	out := make([]byte, 0, 5+len(payload)+4)
+	out = append(out, wireMagic)
+	out = binary.BigEndian.AppendUint32(out, uint32(id))
+	out = appendMessageIndexes(out, msg.ProtoReflect().Descriptor())
+	return append(out, payload...), nil
+}
+
+// schemaID returns the ID of the latest schema under subject, once it has
+// been checked to declare md.
+func (s *ProtobufSerializer) schemaID(subject string, md protoreflect.MessageDescriptor) (int, error) {
+	key := subjectMessage{subject: subject, message: md.FullName()}
+	s.mu.Lock()
+	id, ok := s.ids[key]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	meta, err := s.client.GetLatestSchemaMetadata(subject)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up schema for subject %s: %w", subject, err)
+	}
+	if err := checkProtobufSchema(meta.SchemaInfo, md); err != nil {
+		return 0, fmt.Errorf("schema %d for subject %s: %w", meta.ID, subject, err)
+	}
+
+	s.mu.Lock()
+	s.ids[key] = meta.ID
+	s.mu.Unlock()
+	return meta.ID, nil
+}
+
+var (
+	protoPackagePattern = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+	protoMessagePattern = regexp.MustCompile(`\bmessage\s+(\w+)\s*\{`)
+)
+
+// checkProtobufSchema reports whether schema is a .proto schema declaring
+// md: same package, and every message on md's path declared.
+func checkProtobufSchema(schema schemaregistry.SchemaInfo, md protoreflect.MessageDescriptor) error {
+	if schema.SchemaType != schemaTypeProtobuf {
+		return fmt.Errorf("schema type is %q, want %s", schema.SchemaType, schemaTypeProtobuf)
+	}
+
+	pkg := ""
+	if m := protoPackagePattern.FindStringSubmatch(schema.Schema); m != nil {
+		pkg = m[1]
+	}
+	if want := string(md.ParentFile().Package()); pkg != want {
+		return fmt.Errorf("schema package is %q, want %q", pkg, want)
+	}
+
+	declared := make(map[string]bool)
+	for _, m := range protoMessagePattern.FindAllStringSubmatch(schema.Schema, -1) {
+		declared[m[1]] = true
+	}
+	for d := protoreflect.Descriptor(md); ; d = d.Parent() {
+		if _, ok := d.(protoreflect.MessageDescriptor); !ok {
+			break
+		}
+		if !declared[string(d.Name())] {
+			return fmt.Errorf("schema does not declare message %s", md.FullName())
+		}
+	}
+	return nil
+}
+
+// appendMessageIndexes encodes the path of md within its file as zigzag
+// varints: the path length then each index. The common case of the first
+// top-level message is written as a single 0.
+func appendMessageIndexes(out []byte, md protoreflect.MessageDescriptor) []byte {
+	var path []int
+	for d := protoreflect.Descriptor(md); ; d = d.Parent() {
+		if _, ok := d.(protoreflect.MessageDescriptor); !ok {
+			break
+		}
+		path = append([]int{d.Index()}, path...)
+	}
+
+	if len(path) == 1 && path[0] == 0 {
+		return append(out, 0)
+	}
+	out = binary.AppendVarint(out, int64(len(path)))
+	for _, i := range path {
+		out = binary.AppendVarint(out, int64(i))
+	}
+	return out
+}
+
+// ProtobufDeserializer decodes Confluent wire format payloads into a
+// caller-supplied message. The embedded schema ID is looked up under the
+// topic's value subject and checked to declare the target message, with
+// the same textual check as ProtobufSerializer; results are cached per ID
+// and message type.
+type ProtobufDeserializer struct {
+	client schemaregistry.Client
+
+	mu       sync.Mutex
+	verified map[schemaMessage]bool
+}
+
+// schemaMessage keys the schema IDs verified for a message type.
+type schemaMessage struct {
+	id      int
+	message protoreflect.FullName
+}
+
+func NewProtobufDeserializer(client schemaregistry.Client) *ProtobufDeserializer {
+	return &ProtobufDeserializer{client: client, verified: make(map[schemaMessage]bool)}
+}
+
+// DeserializeInto decodes payload, consumed from topic, into msg.
+func (d *ProtobufDeserializer) DeserializeInto(topic string, payload []byte, msg proto.Message) error {
+	if len(payload) < 5 || payload[0] != wireMagic {
+		return errors.New("payload is not in schema registry wire format")
+	}
+	id := int(binary.BigEndian.Uint32(payload[1:5]))
+	if err := d.verify(topic+"-value", id, msg.ProtoReflect().Descriptor()); err != nil {
+		return err
+	}
+	rest := payload[5:]
+
+	count, n := binary.Varint(rest)
+	if n <= 0 || count < 0 {
+		return errors.New("invalid protobuf message indexes")
+	}
+	rest = rest[n:]
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(rest); n <= 0 {
+			return errors.New("invalid protobuf message indexes")
+		}
+		rest = rest[n:]
+	}
+
+	if err := proto.Unmarshal(rest, msg); err != nil {
+		return fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+	}
+	return nil
+}
+
+// verify checks that schema id, registered under subject, declares md.
+func (d *ProtobufDeserializer) verify(subject string, id int, md protoreflect.MessageDescriptor) error {
+	key := schemaMessage{id: id, message: md.FullName()}
+	d.mu.Lock()
+	ok := d.verified[key]
+	d.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	schema, err := d.client.GetBySubjectAndID(subject, id)
+	if err != nil {
+		return fmt.Errorf("failed to look up schema %d: %w", id, err)
+	}
+	if err := checkProtobufSchema(schema, md); err != nil {
+		return fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	d.mu.Lock()
+	d.verified[key] = true
+	d.mu.Unlock()
+	return nil
+}
+
+// SendProtobufMessage serializes value with the schema registered under
+// subject and produces it to topic.
+func (c *Client) SendProtobufMessage(ctx context.Context, topic string, key []byte, value proto.Message, subject string) error {
+	if c.protobufSerializer == nil {
+		return fmt.Errorf("protobuf serializer not initialized")
+	}
+
+	serializedValue, err := c.protobufSerializer.Serialize(subject, value)
+	if err != nil {
+		return fmt.Errorf("failed to serialize protobuf message: %w", err)
+	}
+
+	return c.SendMessage(ctx, Message{
+		Topic: topic,
+		Key:   key,
+		Value: serializedValue,
+	})
+}
+
+// ConsumeProtobufInto consumes the configured topic, decoding each value
+// into a fresh message from newTarget before passing it to handler.
+// Messages that fail to decode or do not match their schema are skipped or
+// dead-lettered without retrying, according to the handler failure policy;
+// transient schema lookup failures are retried.
+func (c *Client) ConsumeProtobufInto(ctx context.Context, newTarget func() proto.Message, handler func(proto.Message) error) error {
+	if c.protobufDeserializer == nil {
+		return fmt.Errorf("protobuf deserializer not initialized")
+	}
+
+	return c.ConsumeMessages(ctx, func(msg Message) error {
+		target := newTarget()
+		if err := c.protobufDeserializer.DeserializeInto(msg.Topic, msg.Value, target); err != nil {
+			err = fmt.Errorf("failed to deserialize protobuf message: %w", err)
+			if isTransientRegistryError(err) {
+				// The schema lookup may succeed on a retry
+				return err
+			}
+			return retry.Permanent(err)
+		}
+		return handler(target)
+	})
+}
+
+func (c *Client) GetProtobufSerializer() *ProtobufSerializer {
+	return c.protobufSerializer
+}
+
+func (c *Client) GetProtobufDeserializer() *ProtobufDeserializer {
+	return c.protobufDeserializer
+}
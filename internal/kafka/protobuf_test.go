@@ -0,0 +1,241 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestClient_SendProtobufMessageNotInitialized(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+
+	err := client.SendProtobufMessage(context.Background(), "orders", nil, wrapperspb.String("o-1"), "orders-value")
+	if err == nil || !strings.Contains(err.Error(), "protobuf serializer not initialized") {
+		t.Errorf("SendProtobufMessage() error = %v, want not initialized error", err)
+	}
+}
+
+func TestClient_ConsumeProtobufIntoNotInitialized(t *testing.T) {
+	client := &Client{logger: slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))}
+
+	err := client.ConsumeProtobufInto(context.Background(),
+		func() proto.Message { return &wrapperspb.StringValue{} },
+		func(proto.Message) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "protobuf deserializer not initialized") {
+		t.Errorf("ConsumeProtobufInto() error = %v, want not initialized error", err)
+	}
+}
+
+const (
+	timestampSchema = `syntax = "proto3";
+package google.protobuf;
+message Timestamp {
+  int64 seconds = 1;
+  int32 nanos = 2;
+}`
+	stringValueSchema = `syntax = "proto3";
+package google.protobuf;
+message StringValue {
+  string value = 1;
+}`
+)
+
+// registerProtobuf registers schema under subject in registry.
+func registerProtobuf(t *testing.T, registry schemaregistry.Client, subject, schema string) int {
+	t.Helper()
+
+	id, err := registry.Register(subject, schemaregistry.SchemaInfo{
+		Schema:     schema,
+		SchemaType: schemaTypeProtobuf,
+	}, false)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return id
+}
+
+func TestProtobufSerde_RoundTrip(t *testing.T) {
+	registry, err := schemaregistry.NewClient(schemaregistry.NewConfig("mock://protobuf-test"))
+	if err != nil {
+		t.Fatalf("failed to create mock registry: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		topic       string
+		schema      string
+		msg         proto.Message
+		target      proto.Message
+		wantIndexes []byte
+	}{
+		{
+			// Timestamp is the first message in its file
+			name:        "first message",
+			topic:       "timestamps",
+			schema:      timestampSchema,
+			msg:         timestamppb.New(timestamppb.Now().AsTime()),
+			target:      &timestamppb.Timestamp{},
+			wantIndexes: []byte{0},
+		},
+		{
+			// StringValue is the eighth message in wrappers.proto
+			name:        "later message",
+			topic:       "strings",
+			schema:      stringValueSchema,
+			msg:         wrapperspb.String("o-1"),
+			target:      &wrapperspb.StringValue{},
+			wantIndexes: []byte{2, 14},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := tt.topic + "-value"
+			id := registerProtobuf(t, registry, subject, tt.schema)
+
+			payload, err := NewProtobufSerializer(registry).Serialize(subject, tt.msg)
+			if err != nil {
+				t.Fatalf("Serialize() error = %v", err)
+			}
+
+			wantHeader := []byte{0, byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}
+			wantHeader = append(wantHeader, tt.wantIndexes...)
+			if !bytes.HasPrefix(payload, wantHeader) {
+				t.Errorf("payload header = %v, want %v", payload[:len(wantHeader)], wantHeader)
+			}
+
+			if err := NewProtobufDeserializer(registry).DeserializeInto(tt.topic, payload, tt.target); err != nil {
+				t.Fatalf("DeserializeInto() error = %v", err)
+			}
+			if !proto.Equal(tt.target, tt.msg) {
+				t.Errorf("DeserializeInto() = %v, want %v", tt.target, tt.msg)
+			}
+		})
+	}
+}
+
+func TestProtobufSerializer_SchemaMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema schemaregistry.SchemaInfo
+	}{
+		{
+			name:   "different message",
+			schema: schemaregistry.SchemaInfo{Schema: timestampSchema, SchemaType: schemaTypeProtobuf},
+		},
+		{
+			name: "different package",
+			schema: schemaregistry.SchemaInfo{
+				Schema:     `syntax = "proto3"; package orders; message StringValue { string value = 1; }`,
+				SchemaType: schemaTypeProtobuf,
+			},
+		},
+		{
+			name:   "not protobuf",
+			schema: schemaregistry.SchemaInfo{Schema: `{"type": "string"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := schemaregistry.NewClient(schemaregistry.NewConfig("mock://protobuf-mismatch"))
+			if err != nil {
+				t.Fatalf("failed to create mock registry: %v", err)
+			}
+			if _, err := registry.Register("strings-value", tt.schema, false); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			if _, err := NewProtobufSerializer(registry).Serialize("strings-value", wrapperspb.String("x")); err == nil {
+				t.Error("Serialize() expected error for a schema that does not declare the message")
+			}
+		})
+	}
+}
+
+func TestProtobufDeserializer_SchemaMismatch(t *testing.T) {
+	registry, err := schemaregistry.NewClient(schemaregistry.NewConfig("mock://protobuf-decode-mismatch"))
+	if err != nil {
+		t.Fatalf("failed to create mock registry: %v", err)
+	}
+	registerProtobuf(t, registry, "strings-value", stringValueSchema)
+
+	payload, err := NewProtobufSerializer(registry).Serialize("strings-value", wrapperspb.String("x"))
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+
+	err = NewProtobufDeserializer(registry).DeserializeInto("strings", payload, &timestamppb.Timestamp{})
+	if err == nil || !strings.Contains(err.Error(), "does not declare message google.protobuf.Timestamp") {
+		t.Errorf("DeserializeInto() error = %v, want schema mismatch", err)
+	}
+}
+
+// countingRegistry counts schema lookups made through it.
+type countingRegistry struct {
+	schemaregistry.Client
+	latest int
+	byID   int
+}
+
+func (r *countingRegistry) GetLatestSchemaMetadata(subject string) (schemaregistry.SchemaMetadata, error) {
+	r.latest++
+	return r.Client.GetLatestSchemaMetadata(subject)
+}
+
+func (r *countingRegistry) GetBySubjectAndID(subject string, id int) (schemaregistry.SchemaInfo, error) {
+	r.byID++
+	return r.Client.GetBySubjectAndID(subject, id)
+}
+
+func TestProtobufSerde_CachesSchemas(t *testing.T) {
+	mock, err := schemaregistry.NewClient(schemaregistry.NewConfig("mock://protobuf-cache"))
+	if err != nil {
+		t.Fatalf("failed to create mock registry: %v", err)
+	}
+	registerProtobuf(t, mock, "strings-value", stringValueSchema)
+	registry := &countingRegistry{Client: mock}
+
+	serializer := NewProtobufSerializer(registry)
+	deserializer := NewProtobufDeserializer(registry)
+	for i := 0; i < 3; i++ {
+		payload, err := serializer.Serialize("strings-value", wrapperspb.String("x"))
+		if err != nil {
+			t.Fatalf("Serialize() error = %v", err)
+		}
+		if err := deserializer.DeserializeInto("strings", payload, &wrapperspb.StringValue{}); err != nil {
+			t.Fatalf("DeserializeInto() error = %v", err)
+		}
+	}
+
+	if registry.latest != 1 || registry.byID != 1 {
+		t.Errorf("registry lookups = %d latest, %d by ID, want 1 each", registry.latest, registry.byID)
+	}
+}
+
+func TestProtobufSerde_Errors(t *testing.T) {
+	registry, err := schemaregistry.NewClient(schemaregistry.NewConfig("mock://protobuf-errors"))
+	if err != nil {
+		t.Fatalf("failed to create mock registry: %v", err)
+	}
+
+	if _, err := NewProtobufSerializer(registry).Serialize("unknown-value", wrapperspb.String("x")); err == nil {
+		t.Error("Serialize() expected error for a subject with no registered schema")
+	}
+
+	if err := NewProtobufDeserializer(registry).DeserializeInto("unknown", []byte("plain"), &wrapperspb.StringValue{}); err == nil {
+		t.Error("DeserializeInto() expected error for a payload without the wire format header")
+	}
+
+	unknownID := []byte{0, 0, 0, 0, 42, 0}
+	if err := NewProtobufDeserializer(registry).DeserializeInto("unknown", unknownID, &wrapperspb.StringValue{}); err == nil {
+		t.Error("DeserializeInto() expected error for an unregistered schema ID")
+	}
+}
@@ -0,0 +1,142 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// ErrNotSubscribed is returned when seeking a topic none of whose
+// partitions are currently assigned to the consumer.
+var ErrNotSubscribed = errors.New("no partitions assigned for topic")
+
+// seekQueryTimeout bounds the offset lookup made by SeekToTimestamp when ctx
+// has no deadline.
+const seekQueryTimeout = 5 * time.Second
+
+// SeekToTimestamp rewinds (or fast-forwards) every assigned partition of
+// topic to the first message at or after ts, so events since ts are
+// reprocessed. Partitions with no message after ts are moved to their end.
+// It must be called while consuming, after partitions have been assigned.
+func (c *Client) SeekToTimestamp(ctx context.Context, topic string, ts time.Time) error {
+	if ts.IsZero() {
+		return fmt.Errorf("timestamp is required")
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	partitions, err := c.assignedPartitions(topic)
+	if err != nil {
+		return err
+	}
+
+	timeoutMs := int(seekQueryTimeout.Milliseconds())
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutMs = max(int(time.Until(deadline).Milliseconds()), 1)
+	}
+
+	for i := range partitions {
+		partitions[i].Offset = kafka.Offset(ts.UnixMilli())
+	}
+	found, err := c.consumer.OffsetsForTimes(partitions, timeoutMs)
+	if err != nil {
+		return fmt.Errorf("failed to look up offsets for %s at %s: %w", topic, ts.Format(time.RFC3339), err)
+	}
+	offsets, err := timestampOffsets(found)
+	if err != nil {
+		return err
+	}
+	return c.seek(offsets)
+}
+
+// timestampOffsets turns OffsetsForTimes results into seek positions,
+// moving partitions with no message at or after the timestamp (offset -1)
+// to their end.
+func timestampOffsets(found []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	offsets := make([]kafka.TopicPartition, len(found))
+	for i, tp := range found {
+		if tp.Error != nil {
+			return nil, fmt.Errorf("failed to look up offset for %s-%d: %w", *tp.Topic, tp.Partition, tp.Error)
+		}
+		offsets[i] = tp
+		if tp.Offset < 0 {
+			offsets[i].Offset = kafka.OffsetEnd
+		}
+	}
+	return offsets, nil
+}
+
+// SeekToBeginning rewinds every assigned partition of topic to its earliest
+// retained message. It must be called while consuming, after partitions
+// have been assigned.
+func (c *Client) SeekToBeginning(topic string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	partitions, err := c.assignedPartitions(topic)
+	if err != nil {
+		return err
+	}
+	for i := range partitions {
+		partitions[i].Offset = kafka.OffsetBeginning
+	}
+	return c.seek(partitions)
+}
+
+// assignedPartitions returns the partitions of topic currently assigned to
+// the consumer. The caller holds c.mu.
+func (c *Client) assignedPartitions(topic string) ([]kafka.TopicPartition, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+	if c.closed {
+		return nil, fmt.Errorf("client is closed")
+	}
+	if !c.consumes() {
+		return nil, c.roleError("consumer")
+	}
+	if c.consumer == nil {
+		return nil, fmt.Errorf("consumer not initialized")
+	}
+
+	assignment, err := c.consumer.Assignment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+
+	var partitions []kafka.TopicPartition
+	for _, tp := range assignment {
+		if *tp.Topic == topic {
+			partitions = append(partitions, tp)
+		}
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotSubscribed, topic)
+	}
+	return partitions, nil
+}
+
+// seek moves each partition to its Offset, logging where it went.
+func (c *Client) seek(partitions []kafka.TopicPartition) error {
+	sought, err := c.consumer.SeekPartitions(partitions)
+	if err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	for _, tp := range sought {
+		if tp.Error != nil {
+			return fmt.Errorf("failed to seek %s-%d: %w", *tp.Topic, tp.Partition, tp.Error)
+		}
+	}
+
+	for _, tp := range partitions {
+		c.logger.Info("consumer seeked",
+			"topic", *tp.Topic,
+			"partition", tp.Partition,
+			"offset", tp.Offset.String())
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/testutil"
+)
+
+func TestClient_SeekArguments(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	tests := []struct {
+		name   string
+		client *Client
+		topic  string
+		ts     time.Time
+	}{
+		{name: "empty topic", client: &Client{logger: logger}, topic: "", ts: time.Now()},
+		{name: "zero timestamp", client: &Client{logger: logger}, topic: "events", ts: time.Time{}},
+		{name: "consumer not initialized", client: &Client{logger: logger}, topic: "events", ts: time.Now()},
+		{name: "producer role", client: &Client{logger: logger, cfg: config.KafkaConfig{Role: RoleProducer}}, topic: "events", ts: time.Now()},
+		{name: "closed", client: &Client{logger: logger, closed: true}, topic: "events", ts: time.Now()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.client.SeekToTimestamp(context.Background(), tt.topic, tt.ts); err == nil {
+				t.Error("expected SeekToTimestamp() to fail")
+			}
+			if tt.ts.IsZero() {
+				return
+			}
+			if err := tt.client.SeekToBeginning(tt.topic); err == nil {
+				t.Error("expected SeekToBeginning() to fail")
+			}
+		})
+	}
+}
+
+func TestClient_SeekNotSubscribed(t *testing.T) {
+	client, err := New(config.KafkaConfig{
+		Brokers:          []string{"localhost:9092"},
+		Topic:            "test-topic",
+		GroupID:          "test-group",
+		SecurityProtocol: "PLAINTEXT",
+		Role:             RoleConsumer,
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	if err := client.SeekToTimestamp(context.Background(), "test-topic", time.Now()); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("SeekToTimestamp() error = %v, want ErrNotSubscribed", err)
+	}
+	if err := client.SeekToBeginning("test-topic"); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("SeekToBeginning() error = %v, want ErrNotSubscribed", err)
+	}
+}
+
+func TestTimestampOffsets(t *testing.T) {
+	topic := "events"
+	lookupErr := kafka.NewError(kafka.ErrUnknownPartition, "unknown partition", false)
+
+	tests := []struct {
+		name    string
+		found   []kafka.TopicPartition
+		want    []kafka.Offset
+		wantErr bool
+	}{
+		{
+			name: "offsets found",
+			found: []kafka.TopicPartition{
+				{Topic: &topic, Partition: 0, Offset: 42},
+				{Topic: &topic, Partition: 1, Offset: 0},
+			},
+			want: []kafka.Offset{42, 0},
+		},
+		{
+			name: "no message after timestamp",
+			found: []kafka.TopicPartition{
+				{Topic: &topic, Partition: 0, Offset: 7},
+				{Topic: &topic, Partition: 1, Offset: -1},
+			},
+			want: []kafka.Offset{7, kafka.OffsetEnd},
+		},
+		{
+			name: "partition error",
+			found: []kafka.TopicPartition{
+				{Topic: &topic, Partition: 0, Offset: 7},
+				{Topic: &topic, Partition: 1, Error: lookupErr},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offsets, err := timestampOffsets(tt.found)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("timestampOffsets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, tp := range offsets {
+				if tp.Partition != tt.found[i].Partition || tp.Offset != tt.want[i] {
+					t.Errorf("offsets[%d] = %v, want partition %d at %v", i, tp, tt.found[i].Partition, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestClient_SeekToBeginning rewinds from inside the handler once the last
+// message is handled and expects every message to be consumed again. The
+// mock cluster cannot look offsets up by timestamp, so SeekToTimestamp is
+// covered by TestTimestampOffsets.
+func TestClient_SeekToBeginning(t *testing.T) {
+	const topic = "seekable"
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to create mock cluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+	if err := cluster.CreateTopic(topic, 1, 1); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	client, err := New(config.KafkaConfig{
+		Brokers:          []string{cluster.BootstrapServers()},
+		Topic:            topic,
+		GroupID:          topic + "-group",
+		SecurityProtocol: "PLAINTEXT",
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	for i := 0; i < 3; i++ {
+		if err := client.SendMessage(context.Background(), Message{Value: []byte(strconv.Itoa(i))}); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var got []string
+	rewound := false
+	_ = client.ConsumeMessages(ctx, func(msg Message) error {
+		got = append(got, string(msg.Value))
+		if string(msg.Value) != "2" {
+			return nil
+		}
+		if rewound {
+			cancel()
+			return nil
+		}
+		rewound = true
+		if err := client.SeekToBeginning(topic); err != nil {
+			t.Errorf("SeekToBeginning() error = %v", err)
+			cancel()
+		}
+		return nil
+	})
+
+	want := []string{"0", "1", "2", "0", "1", "2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("consumed %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// SelfTest produces a probe message to topic and consumes it back within
+// timeout, proving the service has working produce and consume access.
+// A throwaway consumer group is used so the main group's offsets are not
+// touched.
+func (c *Client) SelfTest(ctx context.Context, topic string, timeout time.Duration) error {
+	if !c.produces() || !c.consumes() {
+		return c.roleError("self-test")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	probeID := c.newID()
+	configMap := c.consumerConfig(c.cfg.GroupID + "-selftest-" + probeID)
+	consumer, err := kafka.NewConsumer(&configMap)
+	if err != nil {
+		return fmt.Errorf("failed to create self-test consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	if err := consumer.SubscribeTopics([]string{topic}, nil); err != nil {
+		return fmt.Errorf("failed to subscribe to self-test topic %s: %w", topic, err)
+	}
+
+	receive := func(ctx context.Context) (Message, error) {
+		for {
+			if err := ctx.Err(); err != nil {
+				return Message{}, err
+			}
+
+			msg, err := consumer.ReadMessage(500 * time.Millisecond)
+			if err != nil {
+				var kafkaErr kafka.Error
+				if errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrTimedOut {
+					continue
+				}
+				return Message{}, err
+			}
+			return Message{Topic: topic, Key: msg.Key, Value: msg.Value}, nil
+		}
+	}
+
+	if err := runSelfTest(ctx, topic, probeID, c.SendMessage, receive); err != nil {
+		return err
+	}
+
+	c.logger.Info("kafka self-test passed", "topic", topic)
+	return nil
+}
+
+// runSelfTest sends a probe keyed by probeID and reads messages until the
+// probe comes back. Earlier messages on the topic are skipped.
+func runSelfTest(
+	ctx context.Context,
+	topic, probeID string,
+	send func(context.Context, Message) error,
+	receive func(context.Context) (Message, error),
+) error {
+	probe := Message{Topic: topic, Key: []byte(probeID), Value: []byte("selftest")}
+	if err := send(ctx, probe); err != nil {
+		return fmt.Errorf("self-test produce failed: %w", err)
+	}
+
+	for {
+		msg, err := receive(ctx)
+		if err != nil {
+			return fmt.Errorf("self-test consume failed: %w", err)
+		}
+		if string(msg.Key) == probeID {
+			return nil
+		}
+	}
+}
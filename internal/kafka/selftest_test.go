@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeTopic is an in-memory topic for exercising the self-test roundtrip.
+type fakeTopic struct {
+	messages chan Message
+	drop     bool
+}
+
+func (f *fakeTopic) send(ctx context.Context, msg Message) error {
+	if !f.drop {
+		f.messages <- msg
+	}
+	return nil
+}
+
+func (f *fakeTopic) receive(ctx context.Context) (Message, error) {
+	select {
+	case msg := <-f.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func TestRunSelfTest_Roundtrip(t *testing.T) {
+	topic := &fakeTopic{messages: make(chan Message, 10)}
+	// A leftover probe from an earlier run must be skipped
+	topic.messages <- Message{Key: []byte("old-probe")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := runSelfTest(ctx, "selftest", "probe-1", topic.send, topic.receive); err != nil {
+		t.Errorf("runSelfTest() error = %v", err)
+	}
+}
+
+func TestRunSelfTest_Timeout(t *testing.T) {
+	topic := &fakeTopic{messages: make(chan Message, 10), drop: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := runSelfTest(ctx, "selftest", "probe-1", topic.send, topic.receive)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("runSelfTest() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRunSelfTest_ProduceFailure(t *testing.T) {
+	denied := errors.New("topic authorization failed")
+	send := func(context.Context, Message) error { return denied }
+	receive := func(context.Context) (Message, error) {
+		t.Fatal("receive called after produce failure")
+		return Message{}, nil
+	}
+
+	err := runSelfTest(context.Background(), "selftest", "probe-1", send, receive)
+	if !errors.Is(err, denied) {
+		t.Errorf("runSelfTest() error = %v, want %v", err, denied)
+	}
+}
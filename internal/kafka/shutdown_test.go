@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+)
+
+// newShutdownClient returns a client whose topic on a mock cluster holds
+// three messages, valued "0" to "2", in one partition.
+func newShutdownClient(t *testing.T, topic string) (*Client, *kafka.MockCluster) {
+	t.Helper()
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to create mock cluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+	if err := cluster.CreateTopic(topic, 1, 1); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	client, err := New(config.KafkaConfig{
+		Brokers:          []string{cluster.BootstrapServers()},
+		Topic:            topic,
+		GroupID:          topic + "-group",
+		SecurityProtocol: "PLAINTEXT",
+		ShutdownGrace:    5 * time.Second,
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	topicName := topic
+	for i := 0; i < 3; i++ {
+		err := client.producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topicName, Partition: 0},
+			Value:          []byte(strconv.Itoa(i)),
+		}, nil)
+		if err != nil {
+			t.Fatalf("Produce() error = %v", err)
+		}
+	}
+	if remaining := client.producer.Flush(10000); remaining != 0 {
+		t.Fatalf("%d messages not flushed", remaining)
+	}
+	return client, cluster
+}
+
+// committedOffset reads group's committed offset for partition 0 of topic
+// through a separate consumer, so it works after the client is closed.
+func committedOffset(t *testing.T, cluster *kafka.MockCluster, group, topic string) kafka.Offset {
+	t.Helper()
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"group.id":          group,
+	})
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+	defer consumer.Close()
+
+	committed, err := consumer.Committed([]kafka.TopicPartition{{Topic: &topic, Partition: 0}}, 5000)
+	if err != nil {
+		t.Fatalf("Committed() error = %v", err)
+	}
+	return committed[0].Offset
+}
+
+func TestClient_ConsumeShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		// stop shuts consumption down while the first message is handled
+		stop func(cancel context.CancelFunc, client *Client)
+	}{
+		{
+			name: "context cancelled",
+			stop: func(cancel context.CancelFunc, client *Client) { cancel() },
+		},
+		{
+			name: "client closed",
+			stop: func(cancel context.CancelFunc, client *Client) {
+				if err := client.Close(); err != nil {
+					t.Errorf("Close() error = %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, cluster := newShutdownClient(t, "shutdown")
+
+			started := make(chan struct{})
+			var handled, finished atomic.Int32
+			handler := func(Message) error {
+				if handled.Add(1) == 1 {
+					close(started)
+				}
+				time.Sleep(200 * time.Millisecond)
+				finished.Add(1)
+				return nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			consumed := make(chan error, 1)
+			go func() {
+				consumed <- client.ConsumeMessages(ctx, handler)
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(30 * time.Second):
+				t.Fatal("no message consumed")
+			}
+			tt.stop(cancel, client)
+
+			select {
+			case err := <-consumed:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("ConsumeMessages() error = %v, want context.Canceled", err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("ConsumeMessages() did not return after shutdown")
+			}
+
+			// The in-flight handler finished and no further message started
+			if handled.Load() != 1 || finished.Load() != 1 {
+				t.Errorf("handled %d, finished %d messages, want 1 and 1", handled.Load(), finished.Load())
+			}
+
+			// The finished message was committed before the consumer closed
+			if offset := committedOffset(t, cluster, "shutdown-group", "shutdown"); offset != 1 {
+				t.Errorf("committed offset = %v, want 1", offset)
+			}
+
+			if err := client.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
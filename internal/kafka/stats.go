@@ -0,0 +1,179 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Stats is the subset of librdkafka's statistics JSON exposed as metrics.
+// See STATISTICS.md in librdkafka for the full payload.
+type Stats struct {
+	// Name is the client instance name and Type is producer or consumer.
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// MsgCount is the number of messages waiting in producer queues.
+	MsgCount int64 `json:"msg_cnt"`
+	// ReplyQueue is the number of ops waiting to be served by polling.
+	ReplyQueue int64                  `json:"replyq"`
+	Brokers    map[string]BrokerStats `json:"brokers"`
+	Topics     map[string]TopicStats  `json:"topics"`
+}
+
+type BrokerStats struct {
+	Name string `json:"name"`
+	// OutbufCount is requests waiting to be sent and WaitRespCount those
+	// sent but not yet answered.
+	OutbufCount   int64       `json:"outbuf_cnt"`
+	WaitRespCount int64       `json:"waitresp_cnt"`
+	RTT           WindowStats `json:"rtt"`
+}
+
+// WindowStats summarizes a rolling window of microsecond samples.
+type WindowStats struct {
+	Avg int64 `json:"avg"`
+	P99 int64 `json:"p99"`
+}
+
+type TopicStats struct {
+	Partitions map[string]PartitionStats `json:"partitions"`
+}
+
+type PartitionStats struct {
+	Partition int32 `json:"partition"`
+	// MsgqCount and XmitMsgqCount are producer queue depths; FetchqCount
+	// is the consumer's pre-fetched message count.
+	MsgqCount     int64 `json:"msgq_cnt"`
+	XmitMsgqCount int64 `json:"xmit_msgq_cnt"`
+	FetchqCount   int64 `json:"fetchq_cnt"`
+	// ConsumerLag is -1 until the consumer knows both its position and
+	// the high watermark.
+	ConsumerLag int64 `json:"consumer_lag"`
+}
+
+// parseStats decodes a statistics event payload.
+func parseStats(payload string) (Stats, error) {
+	var s Stats
+	if err := json.Unmarshal([]byte(payload), &s); err != nil {
+		return Stats{}, fmt.Errorf("failed to parse kafka statistics: %w", err)
+	}
+	return s, nil
+}
+
+// statsMetrics holds the gauges updated from statistics events.
+type statsMetrics struct {
+	brokerRTT   *prometheus.GaugeVec
+	queueDepth  *prometheus.GaugeVec
+	replyQueue  *prometheus.GaugeVec
+	consumerLag *prometheus.GaugeVec
+}
+
+func newStatsMetrics(reg *prometheus.Registry) *statsMetrics {
+	m := &statsMetrics{
+		brokerRTT: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_broker_rtt_seconds",
+			Help: "Average broker round-trip time over the last statistics window.",
+		}, []string{"client", "broker"}),
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_producer_queue_messages",
+			Help: "Messages waiting in producer queues.",
+		}, []string{"client"}),
+		replyQueue: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_reply_queue_ops",
+			Help: "Ops waiting in the client's reply queue to be polled.",
+		}, []string{"client"}),
+		consumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag_messages",
+			Help: "Messages between the consumer's position and the high watermark.",
+		}, []string{"topic", "partition"}),
+	}
+	reg.MustRegister(m.brokerRTT, m.queueDepth, m.replyQueue, m.consumerLag)
+	return m
+}
+
+// WithStatsRegistry exposes the gauges parsed from librdkafka statistics
+// on reg. Statistics are only emitted when the stats interval is
+// configured; without a registry they are logged at debug level only.
+func WithStatsRegistry(reg *prometheus.Registry) Option {
+	return func(c *Client) {
+		if reg != nil {
+			c.stats = newStatsMetrics(reg)
+		}
+	}
+}
+
+// statsConfig enables statistics events on configMap when an interval is
+// configured.
+func (c *Client) statsConfig(configMap kafka.ConfigMap) {
+	if c.cfg.StatsInterval > 0 {
+		configMap["statistics.interval.ms"] = int(c.cfg.StatsInterval.Milliseconds())
+	}
+}
+
+// handleStats parses a statistics event, logs a summary and updates the
+// stats gauges when a registry is configured.
+func (c *Client) handleStats(payload string) {
+	s, err := parseStats(payload)
+	if err != nil {
+		c.logger.Warn("ignoring kafka statistics", "error", err)
+		return
+	}
+
+	var maxLag int64
+	for topic, t := range s.Topics {
+		for _, p := range t.Partitions {
+			// Partition -1 is librdkafka's internal unassigned partition
+			if p.Partition < 0 || p.ConsumerLag < 0 {
+				continue
+			}
+			maxLag = max(maxLag, p.ConsumerLag)
+			if c.stats != nil {
+				c.stats.consumerLag.WithLabelValues(topic, strconv.Itoa(int(p.Partition))).Set(float64(p.ConsumerLag))
+			}
+		}
+	}
+
+	if c.stats != nil {
+		for _, b := range s.Brokers {
+			rtt := time.Duration(b.RTT.Avg) * time.Microsecond
+			c.stats.brokerRTT.WithLabelValues(s.Type, b.Name).Set(rtt.Seconds())
+		}
+		c.stats.queueDepth.WithLabelValues(s.Type).Set(float64(s.MsgCount))
+		c.stats.replyQueue.WithLabelValues(s.Type).Set(float64(s.ReplyQueue))
+	}
+
+	c.logger.Debug("kafka statistics",
+		"client", s.Type,
+		"brokers", len(s.Brokers),
+		"queued_messages", s.MsgCount,
+		"reply_queue", s.ReplyQueue,
+		"max_consumer_lag", maxLag)
+}
+
+// readMessage polls consumer for up to timeout like ReadMessage, handling
+// statistics events instead of discarding them.
+func (c *Client) readMessage(consumer *kafka.Consumer, timeout time.Duration) (*kafka.Message, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, kafka.NewError(kafka.ErrTimedOut, "", false)
+		}
+
+		switch ev := consumer.Poll(int(remaining.Milliseconds())).(type) {
+		case *kafka.Message:
+			if ev.TopicPartition.Error != nil {
+				return ev, ev.TopicPartition.Error
+			}
+			return ev, nil
+		case kafka.Error:
+			return nil, ev
+		case *kafka.Stats:
+			c.handleStats(ev.String())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sampleStats is a trimmed librdkafka statistics payload.
+const sampleStats = `{
+	"name": "go-base-ms-consumer#consumer-1",
+	"type": "consumer",
+	"msg_cnt": 3,
+	"replyq": 2,
+	"brokers": {
+		"localhost:9092/1": {
+			"name": "localhost:9092/1",
+			"outbuf_cnt": 1,
+			"waitresp_cnt": 4,
+			"rtt": {"min": 800, "max": 4000, "avg": 1500, "p99": 3900}
+		}
+	},
+	"topics": {
+		"events": {
+			"topic": "events",
+			"partitions": {
+				"0": {"partition": 0, "msgq_cnt": 0, "xmit_msgq_cnt": 0, "fetchq_cnt": 12, "consumer_lag": 42},
+				"1": {"partition": 1, "msgq_cnt": 0, "xmit_msgq_cnt": 0, "fetchq_cnt": 0, "consumer_lag": -1},
+				"-1": {"partition": -1, "msgq_cnt": 0, "xmit_msgq_cnt": 0, "fetchq_cnt": 0, "consumer_lag": -1}
+			}
+		}
+	}
+}`
+
+func TestParseStats(t *testing.T) {
+	s, err := parseStats(sampleStats)
+	if err != nil {
+		t.Fatalf("parseStats() error = %v", err)
+	}
+
+	if s.Type != "consumer" || s.MsgCount != 3 || s.ReplyQueue != 2 {
+		t.Errorf("parseStats() = type %q msg_cnt %d replyq %d, want consumer 3 2", s.Type, s.MsgCount, s.ReplyQueue)
+	}
+
+	b, ok := s.Brokers["localhost:9092/1"]
+	if !ok {
+		t.Fatalf("broker missing from %v", s.Brokers)
+	}
+	if b.RTT.Avg != 1500 || b.RTT.P99 != 3900 || b.OutbufCount != 1 || b.WaitRespCount != 4 {
+		t.Errorf("broker stats = %+v", b)
+	}
+
+	p := s.Topics["events"].Partitions["0"]
+	if p.ConsumerLag != 42 || p.FetchqCount != 12 {
+		t.Errorf("partition 0 stats = %+v, want lag 42 fetchq 12", p)
+	}
+
+	if _, err := parseStats("{not json"); err == nil {
+		t.Error("parseStats() expected error for invalid JSON")
+	}
+}
+
+func TestClient_HandleStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	logs := &bytes.Buffer{}
+	c := &Client{logger: slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	WithStatsRegistry(reg)(c)
+
+	c.handleStats(sampleStats)
+
+	tests := []struct {
+		metric string
+		labels map[string]string
+		want   float64
+	}{
+		{"kafka_broker_rtt_seconds", map[string]string{"client": "consumer", "broker": "localhost:9092/1"}, 0.0015},
+		{"kafka_producer_queue_messages", map[string]string{"client": "consumer"}, 3},
+		{"kafka_reply_queue_ops", map[string]string{"client": "consumer"}, 2},
+		{"kafka_consumer_lag_messages", map[string]string{"topic": "events", "partition": "0"}, 42},
+	}
+	for _, tt := range tests {
+		if got := gaugeValue(t, reg, tt.metric, tt.labels); got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.metric, tt.labels, got, tt.want)
+		}
+	}
+
+	// Unknown lag and the internal partition are not exported
+	if got := gaugeValue(t, reg, "kafka_consumer_lag_messages", map[string]string{"topic": "events", "partition": "1"}); got != -1 {
+		t.Errorf("partition 1 lag exported as %v, want absent", got)
+	}
+
+	if !strings.Contains(logs.String(), "max_consumer_lag=42") {
+		t.Errorf("expected statistics summary in debug log, got %s", logs.String())
+	}
+}
+
+// gaugeValue returns the gauge of metric whose labels match, or -1 when no
+// such series exists.
+func gaugeValue(t *testing.T, reg *prometheus.Registry, metric string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != metric {
+			continue
+		}
+	series:
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue series
+				}
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return -1
+}
@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMissingTenant is returned by SendMessageForTenant when no tenant is given.
+var ErrMissingTenant = errors.New("tenant is required")
+
+// TopicResolver maps a tenant to the topic its messages are produced to.
+type TopicResolver func(tenant string) string
+
+// PatternTopicResolver returns a resolver producing prefix + tenant + suffix,
+// e.g. "events." and "" give "events.acme".
+func PatternTopicResolver(prefix, suffix string) TopicResolver {
+	return func(tenant string) string {
+		return prefix + tenant + suffix
+	}
+}
+
+// WithTopicResolver replaces the default prefix/suffix topic resolver.
+func WithTopicResolver(resolver TopicResolver) Option {
+	return func(c *Client) {
+		if resolver != nil {
+			c.topicResolver = resolver
+		}
+	}
+}
+
+// SendMessageForTenant sends msg to the topic resolved for tenant, overriding
+// any topic already set on the message.
+func (c *Client) SendMessageForTenant(ctx context.Context, tenant string, msg Message) error {
+	if tenant == "" {
+		return ErrMissingTenant
+	}
+
+	msg.Topic = c.topicResolver(tenant)
+	return c.SendMessage(ctx, msg)
+}
@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPatternTopicResolver(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		suffix string
+		tenant string
+		want   string
+	}{
+		{name: "prefix only", prefix: "events.", tenant: "acme", want: "events.acme"},
+		{name: "prefix and suffix", prefix: "events.", suffix: ".v1", tenant: "acme", want: "events.acme.v1"},
+		{name: "no pattern", tenant: "acme", want: "acme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolve := PatternTopicResolver(tt.prefix, tt.suffix)
+			if got := resolve(tt.tenant); got != tt.want {
+				t.Errorf("resolver(%q) = %q, want %q", tt.tenant, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTopicResolver(t *testing.T) {
+	client := &Client{topicResolver: PatternTopicResolver("events.", "")}
+
+	WithTopicResolver(func(tenant string) string { return "tenant-" + tenant + "-events" })(client)
+	if got := client.topicResolver("acme"); got != "tenant-acme-events" {
+		t.Errorf("custom resolver gave %q, want %q", got, "tenant-acme-events")
+	}
+
+	// A nil resolver keeps the current one
+	WithTopicResolver(nil)(client)
+	if got := client.topicResolver("acme"); got != "tenant-acme-events" {
+		t.Errorf("nil resolver replaced existing one, got %q", got)
+	}
+}
+
+func TestClient_SendMessageForTenantMissingTenant(t *testing.T) {
+	client := &Client{topicResolver: PatternTopicResolver("events.", "")}
+
+	err := client.SendMessageForTenant(context.Background(), "", Message{Value: []byte("x")})
+	if !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("SendMessageForTenant() error = %v, want ErrMissingTenant", err)
+	}
+}
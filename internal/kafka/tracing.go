@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies this package's spans.
+const tracerName = "github.com/sksmith/go-base-ms/internal/kafka"
+
+// traceContext reads and writes the W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// WithTracerProvider sets the provider consume spans are started from.
+// Without it the global provider is used, which is a no-op until the
+// service installs one with otel.SetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		if tp != nil {
+			c.tracer = tp.Tracer(tracerName)
+		}
+	}
+}
+
+// headerCarrier adapts message headers to a propagation.TextMapCarrier.
+type headerCarrier map[string][]byte
+
+func (h headerCarrier) Get(key string) string { return string(h[key]) }
+
+func (h headerCarrier) Set(key, value string) { h[key] = []byte(value) }
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// withTraceContext adds the traceparent of the span active in ctx to msg.
+// Without an active span msg is returned unchanged, and trace headers
+// already set on the message take precedence.
+func withTraceContext(ctx context.Context, msg Message) Message {
+	injected := headerCarrier{}
+	traceContext.Inject(ctx, injected)
+	if len(injected) == 0 {
+		return msg
+	}
+
+	headers := make(map[string][]byte, len(injected)+len(msg.Headers))
+	for k, v := range injected {
+		headers[k] = v
+	}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	msg.Headers = headers
+	return msg
+}
+
+// startConsumeSpan starts a consumer span for msg, linked to the span that
+// produced it when msg carries a traceparent.
+func (c *Client) startConsumeSpan(ctx context.Context, msg *kafka.Message) (context.Context, trace.Span) {
+	tp := msg.TopicPartition
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", *tp.Topic),
+			attribute.Int("messaging.kafka.destination.partition", int(tp.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", int64(tp.Offset)),
+		),
+	}
+
+	headers := headerCarrier{}
+	for _, h := range msg.Headers {
+		headers[h.Key] = h.Value
+	}
+	producer := trace.SpanContextFromContext(traceContext.Extract(context.Background(), headers))
+	if producer.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: producer}))
+	}
+
+	return c.tracer.Start(ctx, *tp.Topic+" process", opts...)
+}
+
+// endConsumeSpan records err on span and ends it.
+func endConsumeSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/sksmith/go-base-ms/internal/config"
+	"github.com/sksmith/go-base-ms/internal/testutil"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+const testTraceparent = "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01"
+
+// activeSpanContext returns a context with the span in testTraceparent
+// active.
+func activeSpanContext(t *testing.T) context.Context {
+	t.Helper()
+
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+// recordingTracer records the name, links and span context of started
+// spans, giving each span a trace ID of its own.
+type recordingTracer struct {
+	embedded.Tracer
+	mu    sync.Mutex
+	names []string
+	links []trace.Link
+	spans []trace.SpanContext
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := byte(len(r.spans) + 1)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0xff, n},
+		SpanID:     trace.SpanID{0xff, n},
+		TraceFlags: trace.FlagsSampled,
+	})
+	r.names = append(r.names, name)
+	r.links = append(r.links, cfg.Links()...)
+	r.spans = append(r.spans, sc)
+
+	ctx = trace.ContextWithSpanContext(ctx, sc)
+	return ctx, trace.SpanFromContext(ctx)
+}
+
+type recordingProvider struct {
+	embedded.TracerProvider
+	tracer *recordingTracer
+}
+
+func (p recordingProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return p.tracer
+}
+
+func TestWithTraceContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		headers map[string][]byte
+		want    map[string]string
+	}{
+		{
+			name: "no active span",
+			ctx:  context.Background(),
+			want: nil,
+		},
+		{
+			name: "active span",
+			ctx:  activeSpanContext(t),
+			want: map[string]string{"traceparent": testTraceparent},
+		},
+		{
+			name:    "message header wins",
+			ctx:     activeSpanContext(t),
+			headers: map[string][]byte{"traceparent": []byte("explicit"), "a": []byte("1")},
+			want:    map[string]string{"traceparent": "explicit", "a": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := withTraceContext(tt.ctx, Message{Headers: tt.headers})
+
+			if len(msg.Headers) != len(tt.want) {
+				t.Fatalf("expected %d headers, got %d: %v", len(tt.want), len(msg.Headers), msg.Headers)
+			}
+			for k, v := range tt.want {
+				if string(msg.Headers[k]) != v {
+					t.Errorf("header %s = %q, want %q", k, msg.Headers[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_TracePropagation(t *testing.T) {
+	const topic = "traced"
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to create mock cluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+	if err := cluster.CreateTopic(topic, 1, 1); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	tracer := &recordingTracer{}
+	client, err := New(config.KafkaConfig{
+		Brokers:          []string{cluster.BootstrapServers()},
+		Topic:            topic,
+		GroupID:          topic + "-group",
+		SecurityProtocol: "PLAINTEXT",
+	}, config.SchemaRegistryConfig{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+		WithTracerProvider(recordingProvider{tracer: tracer}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	testutil.CloseAll(t, client)
+
+	if err := client.SendMessage(activeSpanContext(t), Message{Value: []byte("v")}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var received Message
+	var handlerSpan trace.SpanContext
+	_ = client.ConsumeMessages(ctx, func(msg Message) error {
+		received = msg
+		handlerSpan = trace.SpanContextFromContext(msg.Context())
+		cancel()
+		return nil
+	})
+
+	if got := string(received.Headers["traceparent"]); got != testTraceparent {
+		t.Errorf("traceparent header = %q, want %q", got, testTraceparent)
+	}
+	if err := received.Context().Err(); err != nil {
+		t.Errorf("handler context error = %v, want it to outlive consuming", err)
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if len(tracer.names) != 1 || tracer.names[0] != topic+" process" {
+		t.Fatalf("started spans = %v, want [%s process]", tracer.names, topic)
+	}
+	if len(tracer.links) != 1 || tracer.links[0].SpanContext.TraceID().String() != "0102030405060708090a0b0c0d0e0f10" {
+		t.Errorf("span links = %v, want link to the producing span", tracer.links)
+	}
+
+	// The consume span is linked to the producer rather than its child, so
+	// the handler sees the consume span's own trace
+	if !handlerSpan.Equal(tracer.spans[0]) {
+		t.Errorf("handler span context = %v, want the consume span %v", handlerSpan, tracer.spans[0])
+	}
+}
+
+func TestMessage_Context(t *testing.T) {
+	var msg Message
+	if ctx := msg.Context(); ctx != context.Background() {
+		t.Errorf("Context() = %v, want context.Background() for a message not delivered by a consumer", ctx)
+	}
+}
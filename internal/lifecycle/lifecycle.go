@@ -0,0 +1,49 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Manager tracks background workers (consumer loops, schedulers, pollers)
+// so that shutdown can wait for them to drain before clients are closed.
+type Manager struct {
+	wg     sync.WaitGroup
+	logger *slog.Logger
+}
+
+func New(logger *slog.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// Go registers a worker and runs fn in its own goroutine. Workers are
+// expected to return once the context they were started with is cancelled.
+func (m *Manager) Go(name string, fn func()) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.logger.Debug("background worker started", "worker", name)
+		fn()
+		m.logger.Debug("background worker stopped", "worker", name)
+	}()
+}
+
+// Wait blocks until every registered worker has returned or ctx is done.
+func (m *Manager) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background workers did not stop: %w", ctx.Err())
+	}
+}
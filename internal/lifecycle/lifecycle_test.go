@@ -0,0 +1,68 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManager_WaitDrainsWorkers(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	m := New(logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped atomic.Int32
+	for _, name := range []string{"consumer", "scheduler"} {
+		m.Go(name, func() {
+			<-ctx.Done()
+			// Simulate in-progress work finishing after cancellation
+			time.Sleep(50 * time.Millisecond)
+			stopped.Add(1)
+		})
+	}
+
+	cancel()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+
+	if err := m.Wait(waitCtx); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	if got := stopped.Load(); got != 2 {
+		t.Errorf("expected 2 workers to have drained, got %d", got)
+	}
+}
+
+func TestManager_WaitTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	m := New(logger)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	m.Go("stuck", func() {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := m.Wait(ctx); err == nil {
+		t.Error("expected Wait() to fail when a worker does not stop")
+	}
+}
+
+func TestManager_WaitNoWorkers(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	m := New(logger)
+
+	if err := m.Wait(context.Background()); err != nil {
+		t.Errorf("Wait() error = %v, want nil", err)
+	}
+}
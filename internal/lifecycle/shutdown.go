@@ -0,0 +1,47 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Server is the subset of *http.Server used during shutdown.
+type Server interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+// forceFraction of the shutdown timeout is held back for force-closing
+// connections that did not drain.
+const forceFraction = 10
+
+// ShutdownServer stops srv in tiers. It drains gracefully for most of
+// timeout, which returns as soon as the server is idle, and, if requests
+// are still in flight near the deadline, force-closes the remaining
+// connections. Close is never used while nothing is in flight, since a
+// request accepted just before shutdown would be cut off.
+func ShutdownServer(ctx context.Context, srv Server, timeout time.Duration, inFlight func() int64, logger *slog.Logger) error {
+	drain := timeout - timeout/forceFraction
+	drainCtx, cancel := context.WithTimeout(ctx, drain)
+	defer cancel()
+
+	err := srv.Shutdown(drainCtx)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	remaining := inFlight()
+	logger.Warn("graceful drain timed out, forcing server close",
+		"in_flight", remaining,
+		"drain_timeout", drain)
+	if closeErr := srv.Close(); closeErr != nil {
+		return fmt.Errorf("failed to force close server: %w", closeErr)
+	}
+	return fmt.Errorf("forced server close with %d in-flight requests", remaining)
+}
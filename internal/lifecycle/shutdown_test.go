@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeServer struct {
+	shutdownCalled bool
+	closeCalled    bool
+	// drainFor is how long Shutdown takes; it gives up at the ctx deadline
+	drainFor time.Duration
+}
+
+func (s *fakeServer) Shutdown(ctx context.Context) error {
+	s.shutdownCalled = true
+	select {
+	case <-time.After(s.drainFor):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *fakeServer) Close() error {
+	s.closeCalled = true
+	return nil
+}
+
+func TestShutdownServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		inFlight     int64
+		drainFor     time.Duration
+		wantShutdown bool
+		wantClose    bool
+		wantErr      bool
+		wantLog      string
+		maxElapsed   time.Duration
+	}{
+		{
+			name:         "immediate when idle",
+			inFlight:     0,
+			drainFor:     0,
+			wantShutdown: true,
+			maxElapsed:   20 * time.Millisecond,
+		},
+		{
+			name:         "graceful drain",
+			inFlight:     2,
+			drainFor:     10 * time.Millisecond,
+			wantShutdown: true,
+		},
+		{
+			name:         "forced after drain timeout",
+			inFlight:     3,
+			drainFor:     time.Hour,
+			wantShutdown: true,
+			wantClose:    true,
+			wantErr:      true,
+			wantLog:      "forcing server close",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(buf, nil))
+			srv := &fakeServer{drainFor: tt.drainFor}
+
+			start := time.Now()
+			err := ShutdownServer(context.Background(), srv, 100*time.Millisecond,
+				func() int64 { return tt.inFlight }, logger)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ShutdownServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if srv.shutdownCalled != tt.wantShutdown {
+				t.Errorf("Shutdown called = %v, want %v", srv.shutdownCalled, tt.wantShutdown)
+			}
+			if srv.closeCalled != tt.wantClose {
+				t.Errorf("Close called = %v, want %v", srv.closeCalled, tt.wantClose)
+			}
+			maxElapsed := tt.maxElapsed
+			if maxElapsed == 0 {
+				maxElapsed = 100 * time.Millisecond
+			}
+			if elapsed := time.Since(start); elapsed > maxElapsed {
+				t.Errorf("ShutdownServer() took %v, want within %v", elapsed, maxElapsed)
+			}
+			if tt.wantLog != "" && !strings.Contains(buf.String(), tt.wantLog) {
+				t.Errorf("expected log to contain %q, got %s", tt.wantLog, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type attrsKey struct{}
+
+// ContextWith returns a context carrying attrs for WithContext to bind, in
+// addition to those already stashed. An attribute replaces an earlier one
+// with the same key.
+func ContextWith(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+
+	existing := contextAttrs(ctx)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, a := range existing {
+		if !hasKey(attrs, a.Key) {
+			merged = append(merged, a)
+		}
+	}
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// WithContext returns the default logger with the attributes stashed by
+// ContextWith, such as the request ID, and the trace and span IDs of the
+// active span bound to every record.
+func WithContext(ctx context.Context) *slog.Logger {
+	attrs := contextAttrs(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()))
+	}
+
+	log := slog.Default()
+	if len(attrs) == 0 {
+		return log
+	}
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return log.With(args...)
+}
+
+// contextAttrs returns a copy of the attributes stashed in ctx.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return append([]slog.Attr(nil), attrs...)
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
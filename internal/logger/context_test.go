@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureDefault points the default logger at a buffer for the test.
+func captureDefault(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	buf := &bytes.Buffer{}
+	slog.SetDefault(NewWithWriter(buf))
+	return buf
+}
+
+// records decodes each JSON line written to buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var out []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("failed to unmarshal log output: %v", err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestWithContext_BindsRequestID(t *testing.T) {
+	buf := captureDefault(t)
+
+	ctx := ContextWith(context.Background(), slog.String("request_id", "req-1"))
+	log := WithContext(ctx)
+	log.Info("loading order")
+	log.Warn("order not found", "order_id", 42)
+
+	got := records(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(got))
+	}
+	for _, record := range got {
+		if record["request_id"] != "req-1" {
+			t.Errorf("record %v missing request_id req-1", record)
+		}
+	}
+}
+
+func TestContextWith(t *testing.T) {
+	ctx := ContextWith(context.Background(), slog.String("request_id", "req-1"), slog.String("tenant", "acme"))
+	child := ContextWith(ctx, slog.String("request_id", "req-2"), slog.Int("attempt", 2))
+
+	want := map[string]string{"tenant": "acme", "request_id": "req-2", "attempt": "2"}
+	attrs := contextAttrs(child)
+	if len(attrs) != len(want) {
+		t.Fatalf("expected %d attrs, got %v", len(want), attrs)
+	}
+	for _, a := range attrs {
+		if want[a.Key] != a.Value.String() {
+			t.Errorf("attr %s = %s, want %s", a.Key, a.Value, want[a.Key])
+		}
+	}
+
+	// The parent context is unchanged
+	if parent := contextAttrs(ctx); len(parent) != 2 || parent[0].Value.String() != "req-1" {
+		t.Errorf("parent attrs changed: %v", parent)
+	}
+}
+
+func TestWithContext_TraceIDs(t *testing.T) {
+	buf := captureDefault(t)
+
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	WithContext(ctx).Info("traced")
+
+	got := records(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(got))
+	}
+	if got[0]["trace_id"] != traceID.String() || got[0]["span_id"] != spanID.String() {
+		t.Errorf("record %v missing trace and span IDs", got[0])
+	}
+}
+
+func TestWithContext_Empty(t *testing.T) {
+	captureDefault(t)
+
+	if got := WithContext(context.Background()); got != slog.Default() {
+		t.Error("expected the default logger for a context without attributes")
+	}
+}
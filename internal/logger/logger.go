@@ -1,59 +1,189 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
+	"math/rand"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 var (
 	currentLevel = new(slog.LevelVar)
 	mu           sync.RWMutex
+
+	// sampleRate holds the float64 bits of the fraction of records below
+	// warn level that are emitted.
+	sampleRate atomic.Uint64
+)
+
+// Config describes the effective logging configuration.
+type Config struct {
+	Level      string  `json:"level"`
+	Format     string  `json:"format"`
+	Schema     string  `json:"schema"`
+	Source     bool    `json:"source"`
+	SampleRate float64 `json:"sample_rate"`
+	// Components lists the named loggers given their own level.
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// Output formats selectable with LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Field schemas selectable with LOG_SCHEMA.
+const (
+	SchemaSlog = "slog"
+	SchemaECS  = "ecs"
 )
 
 func init() {
-	// Set initial level from environment
-	if os.Getenv("LOG_LEVEL") == "debug" {
-		currentLevel.Set(slog.LevelDebug)
-	} else {
-		currentLevel.Set(slog.LevelInfo)
+	initLevel(os.Getenv("LOG_LEVEL"), os.Stderr)
+	sampleRate.Store(math.Float64bits(1))
+}
+
+// initLevel sets the startup level from a LOG_LEVEL value. Unrecognized
+// values are reported to warnings and leave the level at info.
+func initLevel(level string, warnings io.Writer) {
+	currentLevel.Set(slog.LevelInfo)
+	if level == "" {
+		return
+	}
+	if err := SetLevel(level); err != nil {
+		fmt.Fprintf(warnings, "warning: LOG_LEVEL: %v, defaulting to info\n", err)
 	}
 }
 
+// New returns a logger writing to the LOG_FILE path, or to stdout when it
+// is unset or cannot be opened.
 func New() *slog.Logger {
+	return NewWithWriter(output(os.Getenv("LOG_FILE"), os.Stderr))
+}
+
+// NewWithWriter returns a logger writing records to w, configured like New.
+func NewWithWriter(w io.Writer) *slog.Logger {
+	return slog.New(&samplingHandler{Handler: newHandler(w, format(), schema(), serviceName(), addSource())})
+}
+
+// output opens path for appending, e.g. for a sidecar tailing the file.
+// An empty path or an open failure, reported to warnings, selects stdout.
+// The file stays open for the life of the process.
+func output(path string, warnings io.Writer) io.Writer {
+	if path == "" {
+		return os.Stdout
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		fmt.Fprintf(warnings, "warning: LOG_FILE: %v, logging to stdout\n", err)
+		return os.Stdout
+	}
+	return f
+}
+
+// newHandler returns the JSON or key=value text handler for format and
+// schema. The ECS schema renames the built-in fields to @timestamp,
+// log.level and message and adds service.name to every record. source
+// adds the caller's file and line to every record.
+func newHandler(w io.Writer, format, schema, service string, source bool) slog.Handler {
 	opts := &slog.HandlerOptions{
-		Level: currentLevel,
+		Level:     currentLevel,
+		AddSource: source,
+	}
+	if schema == SchemaECS {
+		opts.ReplaceAttr = ecsAttr
+	}
+
+	var h slog.Handler
+	if format == FormatText {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	if schema != SchemaECS {
+		return h
+	}
+	return h.WithAttrs([]slog.Attr{
+		slog.String("service.name", service),
+	})
+}
+
+// ecsAttr maps the built-in slog keys to their ECS field names.
+func ecsAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
 	}
 
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	return slog.New(handler)
+	switch a.Key {
+	case slog.TimeKey:
+		a.Key = "@timestamp"
+	case slog.LevelKey:
+		a.Key = "log.level"
+		a.Value = slog.StringValue(strings.ToLower(a.Value.String()))
+	case slog.MessageKey:
+		a.Key = "message"
+	}
+	return a
 }
 
-func SetLevel(level string) error {
-	mu.Lock()
-	defer mu.Unlock()
+// format returns the LOG_FORMAT output format, defaulting to JSON.
+func format() string {
+	if os.Getenv("LOG_FORMAT") == FormatText {
+		return FormatText
+	}
+	return FormatJSON
+}
 
+// addSource reports whether LOG_SOURCE enables source locations. They cost
+// a stack lookup per record, so they are off unless set to true.
+func addSource() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("LOG_SOURCE"))
+	return enabled
+}
+
+// schema returns the LOG_SCHEMA field schema, defaulting to plain slog.
+func schema() string {
+	if os.Getenv("LOG_SCHEMA") == SchemaECS {
+		return SchemaECS
+	}
+	return SchemaSlog
+}
+
+func serviceName() string {
+	if name := os.Getenv("SERVICE_NAME"); name != "" {
+		return name
+	}
+	return "go-base-ms"
+}
+
+// parseLevel maps a level name to its slog level.
+func parseLevel(level string) (slog.Level, error) {
 	switch level {
 	case "debug":
-		currentLevel.Set(slog.LevelDebug)
+		return slog.LevelDebug, nil
 	case "info":
-		currentLevel.Set(slog.LevelInfo)
+		return slog.LevelInfo, nil
 	case "warn":
-		currentLevel.Set(slog.LevelWarn)
+		return slog.LevelWarn, nil
 	case "error":
-		currentLevel.Set(slog.LevelError)
+		return slog.LevelError, nil
 	default:
-		return fmt.Errorf("invalid log level: %s", level)
+		return 0, fmt.Errorf("invalid log level: %s", level)
 	}
-	return nil
 }
 
-func GetLevel() string {
-	mu.RLock()
-	defer mu.RUnlock()
-
-	switch currentLevel.Level() {
+// levelName maps a slog level back to its name.
+func levelName(level slog.Level) string {
+	switch level {
 	case slog.LevelDebug:
 		return "debug"
 	case slog.LevelInfo:
@@ -66,3 +196,116 @@ func GetLevel() string {
 		return "unknown"
 	}
 }
+
+func SetLevel(level string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, err := swapLevel(level)
+	return err
+}
+
+// ChangeLevel sets level and logs the change on log as one record with the
+// previous and new level. The previous level is read under the same lock
+// as the update and the record is written before the lock is released, so
+// concurrent changes (admin API, signals, file watchers) are logged in the
+// order they took effect. It returns the previous level.
+func ChangeLevel(log *slog.Logger, level string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous, err := swapLevel(level)
+	if err != nil {
+		return "", err
+	}
+	log.Info(fmt.Sprintf("log level changed from %s to %s", previous, level),
+		"previous_level", previous,
+		"new_level", level)
+	return previous, nil
+}
+
+// swapLevel sets level and returns the level it replaced. The caller must
+// hold mu.
+func swapLevel(level string) (string, error) {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return "", err
+	}
+	previous := levelName(currentLevel.Level())
+	currentLevel.Set(parsed)
+	return previous, nil
+}
+
+func GetLevel() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return levelName(currentLevel.Level())
+}
+
+// SetSampleRate sets the fraction (0 to 1) of debug and info records that
+// are emitted. Warnings and errors are never sampled.
+func SetSampleRate(rate float64) error {
+	if math.IsNaN(rate) || rate < 0 || rate > 1 {
+		return fmt.Errorf("invalid sample rate: %v", rate)
+	}
+	sampleRate.Store(math.Float64bits(rate))
+	return nil
+}
+
+func GetSampleRate() float64 {
+	return math.Float64frombits(sampleRate.Load())
+}
+
+// GetConfig returns the effective logging configuration.
+func GetConfig() Config {
+	return Config{
+		Level:      GetLevel(),
+		Format:     format(),
+		Schema:     schema(),
+		Source:     addSource(),
+		SampleRate: GetSampleRate(),
+		Components: componentLevels(),
+	}
+}
+
+// componentLevels returns the level of each named logger with its own
+// level set.
+func componentLevels() map[string]string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var levels map[string]string
+	for name, c := range components {
+		if !c.set.Load() {
+			continue
+		}
+		if levels == nil {
+			levels = make(map[string]string)
+		}
+		levels[name] = levelName(c.level.Level())
+	}
+	return levels
+}
+
+// samplingHandler drops a fraction of records below warn level according
+// to the current sample rate.
+type samplingHandler struct {
+	slog.Handler
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn {
+		if rate := GetSampleRate(); rate < 1 && rand.Float64() >= rate {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -3,8 +3,13 @@ package logger
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -89,6 +94,72 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewWithWriter(buf)
+
+	logger.Info("order placed", "order_id", 42)
+	logger.Debug("dropped at info level")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected one JSON record in the writer, got %q: %v", buf.String(), err)
+	}
+	if got["msg"] != "order placed" || got["order_id"] != float64(42) {
+		t.Errorf("unexpected record: %v", got)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStdout bool
+		wantWarn   bool
+	}{
+		{name: "unset", path: "", wantStdout: true},
+		{name: "file", path: filepath.Join(dir, "app.log")},
+		{name: "unopenable", path: filepath.Join(dir, "missing", "app.log"), wantStdout: true, wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings bytes.Buffer
+			w := output(tt.path, &warnings)
+
+			if gotStdout := w == io.Writer(os.Stdout); gotStdout != tt.wantStdout {
+				t.Errorf("stdout selected = %v, want %v", gotStdout, tt.wantStdout)
+			}
+			if gotWarn := warnings.Len() > 0; gotWarn != tt.wantWarn {
+				t.Errorf("warning written = %v, want %v (%q)", gotWarn, tt.wantWarn, warnings.String())
+			}
+		})
+	}
+}
+
+func TestOutput_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	w := output(path, io.Discard)
+	NewWithWriter(w).Info("appended")
+	if f, ok := w.(*os.File); ok {
+		f.Close()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "existing\n") || !strings.Contains(string(data), `"msg":"appended"`) {
+		t.Errorf("log file = %q, want the record appended", data)
+	}
+}
+
 func TestSetLevel(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -139,6 +210,38 @@ func TestSetLevel(t *testing.T) {
 	}
 }
 
+func TestInitLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		want     string
+		wantWarn bool
+	}{
+		{name: "unset", level: "", want: "info"},
+		{name: "debug", level: "debug", want: "debug"},
+		{name: "info", level: "info", want: "info"},
+		{name: "warn", level: "warn", want: "warn"},
+		{name: "error", level: "error", want: "error"},
+		{name: "invalid", level: "verbose", want: "info", wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer currentLevel.Set(slog.LevelInfo)
+
+			var warnings bytes.Buffer
+			initLevel(tt.level, &warnings)
+
+			if got := GetLevel(); got != tt.want {
+				t.Errorf("GetLevel() = %v, want %v", got, tt.want)
+			}
+			if gotWarn := warnings.Len() > 0; gotWarn != tt.wantWarn {
+				t.Errorf("warning written = %v, want %v (%q)", gotWarn, tt.wantWarn, warnings.String())
+			}
+		})
+	}
+}
+
 func TestGetLevel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -176,3 +279,362 @@ func TestGetLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSampleRate(t *testing.T) {
+	defer SetSampleRate(1)
+
+	tests := []struct {
+		name    string
+		rate    float64
+		wantErr bool
+	}{
+		{name: "zero", rate: 0, wantErr: false},
+		{name: "half", rate: 0.5, wantErr: false},
+		{name: "one", rate: 1, wantErr: false},
+		{name: "negative", rate: -0.1, wantErr: true},
+		{name: "above one", rate: 1.5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetSampleRate(1)
+			err := SetSampleRate(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetSampleRate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := tt.rate
+			if tt.wantErr {
+				want = 1
+			}
+			if got := GetSampleRate(); got != want {
+				t.Errorf("GetSampleRate() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSamplingHandler(t *testing.T) {
+	defer SetSampleRate(1)
+	currentLevel.Set(slog.LevelInfo)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(&samplingHandler{Handler: slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level: currentLevel,
+	})})
+
+	SetSampleRate(0)
+	logger.Info("sampled out")
+	if buf.Len() != 0 {
+		t.Errorf("expected info record to be dropped at sample rate 0, got %s", buf.String())
+	}
+
+	logger.Warn("always kept")
+	if buf.Len() == 0 {
+		t.Error("expected warn record to bypass sampling")
+	}
+
+	buf.Reset()
+	SetSampleRate(1)
+	logger.Info("kept")
+	if buf.Len() == 0 {
+		t.Error("expected info record at sample rate 1")
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	defer SetSampleRate(1)
+
+	SetLevel("warn")
+	defer SetLevel("info")
+	SetSampleRate(0.25)
+
+	cfg := GetConfig()
+	if cfg.Level != "warn" {
+		t.Errorf("GetConfig() Level = %v, want warn", cfg.Level)
+	}
+	if cfg.Format != "json" {
+		t.Errorf("GetConfig() Format = %v, want json", cfg.Format)
+	}
+	if cfg.SampleRate != 0.25 {
+		t.Errorf("GetConfig() SampleRate = %v, want 0.25", cfg.SampleRate)
+	}
+}
+
+func TestNewHandler_ECS(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(newHandler(buf, FormatJSON, SchemaECS, "orders", false))
+
+	logger.Warn("disk almost full", "percent", 91)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"log.level":    "warn",
+		"message":      "disk almost full",
+		"service.name": "orders",
+		"percent":      float64(91),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	if _, ok := got["@timestamp"]; !ok {
+		t.Error("missing @timestamp")
+	}
+	for _, key := range []string{"time", "level", "msg"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("unexpected slog field %q in ECS output", key)
+		}
+	}
+}
+
+func TestNewHandler_SlogDefault(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(newHandler(buf, FormatJSON, SchemaSlog, "orders", false))
+
+	logger.Info("hello")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if got["msg"] != "hello" || got["level"] != "INFO" {
+		t.Errorf("unexpected default output: %v", got)
+	}
+	if _, ok := got["service.name"]; ok {
+		t.Error("service.name added outside ECS schema")
+	}
+}
+
+func TestNewHandler_Text(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(newHandler(buf, FormatText, SchemaSlog, "orders", false))
+
+	logger.Info("hello", "order_id", 42)
+
+	out := buf.String()
+	if json.Valid(buf.Bytes()) {
+		t.Fatalf("expected text output, got JSON: %s", out)
+	}
+	for _, want := range []string{"level=INFO", "msg=hello", "order_id=42"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q missing %q", out, want)
+		}
+	}
+
+	// The shared level still applies to text output
+	buf.Reset()
+	SetLevel("warn")
+	defer SetLevel("info")
+	logger.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("expected info record to be dropped at warn level, got %q", buf.String())
+	}
+}
+
+func TestNewHandler_Source(t *testing.T) {
+	tests := []struct {
+		name   string
+		source bool
+	}{
+		{name: "enabled", source: true},
+		{name: "disabled", source: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := slog.New(newHandler(buf, FormatJSON, SchemaSlog, "orders", tt.source))
+
+			logger.Info("hello")
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal log output: %v", err)
+			}
+
+			source, ok := got[slog.SourceKey].(map[string]interface{})
+			if ok != tt.source {
+				t.Fatalf("source present = %v, want %v: %v", ok, tt.source, got)
+			}
+			if tt.source && !strings.HasSuffix(fmt.Sprint(source["file"]), "logger_test.go") {
+				t.Errorf("source file = %v, want logger_test.go", source["file"])
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: FormatJSON},
+		{value: "json", want: FormatJSON},
+		{value: "text", want: FormatText},
+		{value: "pretty", want: FormatJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("LOG_FORMAT", tt.value)
+			if got := format(); got != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeLevel(t *testing.T) {
+	defer currentLevel.Set(slog.LevelInfo)
+	currentLevel.Set(slog.LevelInfo)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	previous, err := ChangeLevel(log, "warn")
+	if err != nil {
+		t.Fatalf("ChangeLevel() error = %v", err)
+	}
+	if previous != "info" {
+		t.Errorf("ChangeLevel() previous = %q, want info", previous)
+	}
+	if got := GetLevel(); got != "warn" {
+		t.Errorf("GetLevel() = %q, want warn", got)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single JSON log record, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "log level changed from info to warn" {
+		t.Errorf("msg = %v, want log level changed from info to warn", entry["msg"])
+	}
+	if entry["previous_level"] != "info" || entry["new_level"] != "warn" {
+		t.Errorf("level attrs = %v/%v, want info/warn", entry["previous_level"], entry["new_level"])
+	}
+
+	buf.Reset()
+	if _, err := ChangeLevel(log, "trace"); err == nil {
+		t.Error("ChangeLevel() expected error for invalid level")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no log for invalid level, got %q", buf.String())
+	}
+	if got := GetLevel(); got != "warn" {
+		t.Errorf("GetLevel() = %q after invalid change, want warn", got)
+	}
+}
+
+// TestLevel_Concurrent hammers level changes and reads from many
+// goroutines. Run with -race.
+func TestLevel_Concurrent(t *testing.T) {
+	defer currentLevel.Set(slog.LevelInfo)
+	currentLevel.Set(slog.LevelInfo)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	levels := []string{"debug", "info", "warn", "error"}
+
+	const workers = 16
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				level := levels[(i+j)%len(levels)]
+				switch j % 3 {
+				case 0:
+					if _, err := ChangeLevel(log, level); err != nil {
+						t.Errorf("ChangeLevel(%q) error = %v", level, err)
+					}
+				case 1:
+					if err := SetLevel(level); err != nil {
+						t.Errorf("SetLevel(%q) error = %v", level, err)
+					}
+				default:
+					if got := GetLevel(); got == "unknown" {
+						t.Errorf("GetLevel() = %q", got)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if got := GetLevel(); got != "debug" {
+		t.Errorf("GetLevel() = %q after concurrent changes, want debug", got)
+	}
+
+	// Each record's previous level must be a valid name, and the number of
+	// records must match the number of ChangeLevel calls
+	records := 0
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decoding log record: %v", err)
+		}
+		records++
+		previous, _ := entry["previous_level"].(string)
+		if _, err := parseLevel(previous); err != nil {
+			t.Errorf("record %d has previous_level %q", records, previous)
+		}
+	}
+	if want := workers * ((iterations + 2) / 3); records != want {
+		t.Errorf("logged %d level changes, want %d", records, want)
+	}
+}
+
+// TestChangeLevel_ConcurrentOrder checks that concurrent changes are logged
+// in the order they took effect: each record's previous level is the level
+// the record before it set.
+func TestChangeLevel_ConcurrentOrder(t *testing.T) {
+	defer currentLevel.Set(slog.LevelInfo)
+	currentLevel.Set(slog.LevelInfo)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	levels := []string{"debug", "info", "warn", "error"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := ChangeLevel(log, levels[(i+j)%len(levels)]); err != nil {
+					t.Errorf("ChangeLevel() error = %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	want := "info"
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decoding log record: %v", err)
+		}
+		if entry["previous_level"] != want {
+			t.Fatalf("previous_level = %v, want %s", entry["previous_level"], want)
+		}
+		want, _ = entry["new_level"].(string)
+	}
+	if got := GetLevel(); got != want {
+		t.Errorf("GetLevel() = %q, want last logged level %q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// components holds the level of every named logger, keyed by name and
+// guarded by mu.
+var components = make(map[string]*componentLevel)
+
+// componentLevel is a named logger's level. Until a level is set for the
+// component it follows the default level.
+type componentLevel struct {
+	level slog.LevelVar
+	set   atomic.Bool
+}
+
+func (c *componentLevel) Level() slog.Level {
+	if c.set.Load() {
+		return c.level.Level()
+	}
+	return currentLevel.Level()
+}
+
+// component returns the level of the named logger, registering it on first
+// use. The caller must hold mu for writing.
+func component(name string) *componentLevel {
+	c, ok := components[name]
+	if !ok {
+		c = &componentLevel{}
+		components[name] = c
+	}
+	return c
+}
+
+// Named returns the default logger tagged with logger=name and filtered by
+// name's own level, so e.g. kafka can log at debug while everything else
+// stays at info. Components without their own level follow SetLevel.
+func Named(name string) *slog.Logger {
+	mu.Lock()
+	level := component(name)
+	mu.Unlock()
+
+	return slog.New(&levelHandler{Handler: slog.Default().Handler(), level: level}).With("logger", name)
+}
+
+// SetLevelFor sets the level of the named logger.
+func SetLevelFor(name, level string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, err := swapLevelFor(name, level)
+	return err
+}
+
+// GetLevelFor returns the effective level of the named logger.
+func GetLevelFor(name string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if c, ok := components[name]; ok {
+		return levelName(c.Level())
+	}
+	return levelName(currentLevel.Level())
+}
+
+// ChangeLevelFor is ChangeLevel for the named logger.
+func ChangeLevelFor(log *slog.Logger, name, level string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous, err := swapLevelFor(name, level)
+	if err != nil {
+		return "", err
+	}
+	log.Info(fmt.Sprintf("log level of %s changed from %s to %s", name, previous, level),
+		"component", name,
+		"previous_level", previous,
+		"new_level", level)
+	return previous, nil
+}
+
+// swapLevelFor sets the named logger's level and returns the effective
+// level it replaced. The caller must hold mu.
+func swapLevelFor(name, level string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("component is required")
+	}
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return "", err
+	}
+
+	c := component(name)
+	previous := levelName(c.Level())
+	c.level.Set(parsed)
+	c.set.Store(true)
+	return previous, nil
+}
+
+// levelHandler filters records by its own level instead of the wrapped
+// handler's. The built-in handlers only check their level in Enabled, so
+// records below it still reach the output when this level is lower.
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
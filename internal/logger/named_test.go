@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// resetComponents clears named levels and restores the default level when
+// the test finishes.
+func resetComponents(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		mu.Lock()
+		components = make(map[string]*componentLevel)
+		mu.Unlock()
+		currentLevel.Set(slog.LevelInfo)
+	})
+}
+
+func TestNamed_IndependentLevels(t *testing.T) {
+	resetComponents(t)
+	buf := captureDefault(t)
+
+	kafka := Named("kafka")
+	http := Named("http")
+
+	if err := SetLevelFor("kafka", "debug"); err != nil {
+		t.Fatalf("SetLevelFor() error = %v", err)
+	}
+
+	kafka.Debug("fetched batch")
+	http.Debug("matched route")
+	slog.Default().Debug("default debug")
+
+	got := records(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("expected only the kafka debug record, got %v", got)
+	}
+	if got[0]["logger"] != "kafka" || got[0]["msg"] != "fetched batch" {
+		t.Errorf("unexpected record: %v", got[0])
+	}
+
+	if GetLevelFor("kafka") != "debug" || GetLevelFor("http") != "info" || GetLevel() != "info" {
+		t.Errorf("levels kafka=%s http=%s default=%s, want debug, info, info",
+			GetLevelFor("kafka"), GetLevelFor("http"), GetLevel())
+	}
+}
+
+func TestNamed_FollowsDefaultLevel(t *testing.T) {
+	resetComponents(t)
+	buf := captureDefault(t)
+
+	http := Named("http")
+	if err := SetLevelFor("kafka", "error"); err != nil {
+		t.Fatalf("SetLevelFor() error = %v", err)
+	}
+
+	// A component without its own level follows the default
+	SetLevel("warn")
+	http.Info("dropped")
+	http.Warn("kept")
+
+	got := records(t, buf)
+	if len(got) != 1 || got[0]["msg"] != "kept" {
+		t.Errorf("expected only the warn record, got %v", got)
+	}
+	if GetLevelFor("http") != "warn" || GetLevelFor("unregistered") != "warn" {
+		t.Errorf("levels http=%s unregistered=%s, want warn", GetLevelFor("http"), GetLevelFor("unregistered"))
+	}
+
+	// Changing the default leaves a component with its own level alone
+	if GetLevelFor("kafka") != "error" {
+		t.Errorf("GetLevelFor(kafka) = %s, want error", GetLevelFor("kafka"))
+	}
+}
+
+func TestSetLevelFor_Invalid(t *testing.T) {
+	resetComponents(t)
+
+	tests := []struct {
+		name      string
+		component string
+		level     string
+	}{
+		{name: "invalid level", component: "kafka", level: "trace"},
+		{name: "empty component", component: "", level: "debug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetLevelFor(tt.component, tt.level); err == nil {
+				t.Error("expected SetLevelFor() to fail")
+			}
+		})
+	}
+	if GetLevelFor("kafka") != "info" {
+		t.Errorf("GetLevelFor(kafka) = %s after failed changes, want info", GetLevelFor("kafka"))
+	}
+}
+
+func TestChangeLevelFor(t *testing.T) {
+	resetComponents(t)
+
+	buf := &bytes.Buffer{}
+	log := slog.New(slog.NewTextHandler(buf, nil))
+
+	previous, err := ChangeLevelFor(log, "kafka", "debug")
+	if err != nil {
+		t.Fatalf("ChangeLevelFor() error = %v", err)
+	}
+	if previous != "info" {
+		t.Errorf("ChangeLevelFor() previous = %s, want info", previous)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"log level of kafka changed from info to debug", "component=kafka", "previous_level=info", "new_level=debug"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q missing %q", out, want)
+		}
+	}
+
+	if cfg := GetConfig(); cfg.Components["kafka"] != "debug" {
+		t.Errorf("GetConfig() Components = %v, want kafka=debug", cfg.Components)
+	}
+}
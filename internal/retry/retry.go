@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Policy controls how Do retries a failing operation.
+type Policy struct {
+	// MaxAttempts is the total number of calls, including the first.
+	// Values below one are treated as one.
+	MaxAttempts int
+	// BaseDelay is the wait after the first failure.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts; zero means no cap.
+	MaxDelay time.Duration
+	// Multiplier grows the delay after each failure; defaults to 2.
+	Multiplier float64
+	// Jitter randomizes each delay by up to ± this fraction (0 to 1) so
+	// callers retrying together spread out.
+	Jitter float64
+	// Retryable classifies errors; nil uses the package Retryable.
+	Retryable func(error) bool
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retryable is the default classifier: everything is retried except
+// context errors and errors wrapped with Permanent.
+func Retryable(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, the
+// attempts run out, or ctx is done. It returns the last error from fn, or
+// ctx.Err() if the context ended first.
+func Do(ctx context.Context, policy Policy, fn func() error) error {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		err = fn()
+		if err == nil || !retryable(err) || attempt >= attempts {
+			return err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt, rand.Float64))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the wait after the given failed attempt (1-based). random
+// returns a value in [0, 1).
+func (p Policy) delay(attempt int, random func() float64) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	d := float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d += d * jitter * (random()*2 - 1)
+	}
+
+	return time.Duration(d)
+}
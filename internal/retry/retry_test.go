@@ -0,0 +1,187 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		random  float64
+		want    time.Duration
+	}{
+		{name: "first attempt", policy: Policy{BaseDelay: 100 * time.Millisecond}, attempt: 1, want: 100 * time.Millisecond},
+		{name: "exponential", policy: Policy{BaseDelay: 100 * time.Millisecond}, attempt: 4, want: 800 * time.Millisecond},
+		{name: "custom multiplier", policy: Policy{BaseDelay: 100 * time.Millisecond, Multiplier: 3}, attempt: 3, want: 900 * time.Millisecond},
+		{name: "capped", policy: Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, attempt: 10, want: time.Second},
+		{name: "jitter low", policy: Policy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}, attempt: 1, random: 0, want: 50 * time.Millisecond},
+		{name: "jitter middle", policy: Policy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}, attempt: 1, random: 0.5, want: 100 * time.Millisecond},
+		{name: "jitter high", policy: Policy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}, attempt: 1, random: 0.75, want: 125 * time.Millisecond},
+		{name: "jitter applied after cap", policy: Policy{BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 0.2}, attempt: 5, random: 0, want: 800 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.delay(tt.attempt, func() float64 { return tt.random })
+			if got != tt.want {
+				t.Errorf("delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTransient := errors.New("transient")
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "succeeds first time",
+			policy:    Policy{MaxAttempts: 3},
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after retries",
+			policy:    Policy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+			failures:  2,
+			err:       errTransient,
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after max attempts",
+			policy:    Policy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+			failures:  5,
+			err:       errTransient,
+			wantCalls: 3,
+			wantErr:   errTransient,
+		},
+		{
+			name:      "zero attempts calls once",
+			policy:    Policy{},
+			failures:  5,
+			err:       errTransient,
+			wantCalls: 1,
+			wantErr:   errTransient,
+		},
+		{
+			name:      "permanent error stops",
+			policy:    Policy{MaxAttempts: 5, BaseDelay: time.Millisecond},
+			failures:  5,
+			err:       Permanent(errTransient),
+			wantCalls: 1,
+			wantErr:   errTransient,
+		},
+		{
+			name: "custom classifier",
+			policy: Policy{
+				MaxAttempts: 5,
+				BaseDelay:   time.Millisecond,
+				Retryable:   func(err error) bool { return false },
+			},
+			failures:  5,
+			err:       errTransient,
+			wantCalls: 1,
+			wantErr:   errTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Do(context.Background(), tt.policy, func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDo_ContextCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Do(ctx, Policy{MaxAttempts: 5, BaseDelay: time.Hour}, func() error {
+			calls++
+			return errors.New("transient")
+		})
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Do() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Do() did not return after context cancellation")
+	}
+
+	if calls != 1 {
+		t.Errorf("Do() made %d calls, want 1", calls)
+	}
+}
+
+func TestDo_ContextAlreadyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := Do(ctx, Policy{MaxAttempts: 3}, func() error {
+		called = true
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() error = %v, want context.Canceled", err)
+	}
+	if called {
+		t.Error("Do() called fn with a cancelled context")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "plain error", err: errors.New("boom"), want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline", err: fmt.Errorf("ping: %w", context.DeadlineExceeded), want: false},
+		{name: "permanent", err: fmt.Errorf("wrapped: %w", Permanent(errors.New("bad input"))), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
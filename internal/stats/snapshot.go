@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+// AssignmentReporter reports the consumer's assigned partitions.
+type AssignmentReporter interface {
+	Assignment() ([]string, error)
+}
+
+// ReadinessReporter reports the most recent readiness result.
+type ReadinessReporter interface {
+	LastReadiness() (health.Check, bool)
+}
+
+// Snapshot logs a point-in-time view of the process for debugging a stuck
+// instance. Any source may be nil.
+func Snapshot(logger *slog.Logger, db PoolStatter, consumer AssignmentReporter, readiness ReadinessReporter) {
+	attrs := []any{
+		"goroutines", runtime.NumGoroutine(),
+	}
+
+	if db != nil {
+		dbStats := db.Stats()
+		attrs = append(attrs,
+			"db_open_connections", dbStats.OpenConnections,
+			"db_in_use", dbStats.InUse,
+			"db_idle", dbStats.Idle,
+			"db_wait_count", dbStats.WaitCount,
+		)
+	}
+
+	if consumer != nil {
+		if assigned, err := consumer.Assignment(); err != nil {
+			attrs = append(attrs, "consumer_assignment_error", err.Error())
+		} else {
+			attrs = append(attrs, "consumer_assignment", assigned)
+		}
+	}
+
+	if readiness != nil {
+		if check, ok := readiness.LastReadiness(); ok {
+			attrs = append(attrs,
+				"last_readiness_status", check.Status,
+				"last_readiness_at", check.Timestamp,
+				"last_readiness_details", check.Details,
+			)
+		} else {
+			attrs = append(attrs, "last_readiness_status", "never run")
+		}
+	}
+
+	logger.Info("status snapshot", attrs...)
+}
+
+// OnSignal calls fn for every signal received until ctx is cancelled.
+func OnSignal(ctx context.Context, signals <-chan os.Signal, fn func()) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			fn()
+		}
+	}
+}
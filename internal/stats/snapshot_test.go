@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sksmith/go-base-ms/internal/health"
+)
+
+type mockAssignment struct {
+	partitions []string
+	err        error
+}
+
+func (m *mockAssignment) Assignment() ([]string, error) {
+	return m.partitions, m.err
+}
+
+type mockReadiness struct {
+	check health.Check
+	ok    bool
+}
+
+func (m *mockReadiness) LastReadiness() (health.Check, bool) {
+	return m.check, m.ok
+}
+
+func TestSnapshot(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	Snapshot(logger,
+		&mockPool{},
+		&mockAssignment{partitions: []string{"events-0", "events-1"}},
+		&mockReadiness{check: health.Check{Status: health.StatusUnhealthy, Timestamp: time.Now()}, ok: true},
+	)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry: %v", err)
+	}
+
+	if entry["msg"] != "status snapshot" {
+		t.Errorf("expected msg 'status snapshot', got %v", entry["msg"])
+	}
+	for _, key := range []string{"goroutines", "db_open_connections", "db_in_use", "consumer_assignment", "last_readiness_status"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("expected snapshot to contain %s, got %v", key, entry)
+		}
+	}
+	if entry["last_readiness_status"] != "unhealthy" {
+		t.Errorf("expected last_readiness_status unhealthy, got %v", entry["last_readiness_status"])
+	}
+	if assigned, _ := entry["consumer_assignment"].([]interface{}); len(assigned) != 2 {
+		t.Errorf("expected 2 assigned partitions, got %v", entry["consumer_assignment"])
+	}
+}
+
+func TestSnapshot_PartialSources(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	Snapshot(logger, nil, &mockAssignment{err: errors.New("consumer not initialized")}, &mockReadiness{})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry: %v", err)
+	}
+
+	if _, ok := entry["db_open_connections"]; ok {
+		t.Error("expected no db stats without a pool")
+	}
+	if entry["consumer_assignment_error"] != "consumer not initialized" {
+		t.Errorf("expected consumer_assignment_error, got %v", entry["consumer_assignment_error"])
+	}
+	if entry["last_readiness_status"] != "never run" {
+		t.Errorf("expected last_readiness_status 'never run', got %v", entry["last_readiness_status"])
+	}
+}
+
+func TestOnSignal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	snapshots := make(chan struct{}, 2)
+
+	done := make(chan struct{})
+	go func() {
+		OnSignal(ctx, signals, func() { snapshots <- struct{}{} })
+		close(done)
+	}()
+
+	signals <- os.Interrupt
+	select {
+	case <-snapshots:
+	case <-time.After(time.Second):
+		t.Fatal("expected snapshot after signal")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("OnSignal did not return after context cancellation")
+	}
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// PoolStatter reports connection pool statistics.
+type PoolStatter interface {
+	Stats() sql.DBStats
+}
+
+// Run logs database pool and runtime statistics every interval until ctx
+// is cancelled. It gives baseline telemetry where metrics aren't scraped.
+func Run(ctx context.Context, interval time.Duration, logger *slog.Logger, db PoolStatter) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			Log(logger, db)
+		}
+	}
+}
+
+// Log emits a single statistics record.
+func Log(logger *slog.Logger, db PoolStatter) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	attrs := []any{
+		"goroutines", runtime.NumGoroutine(),
+		"heap_alloc_bytes", mem.HeapAlloc,
+		"sys_bytes", mem.Sys,
+		"num_gc", mem.NumGC,
+	}
+
+	if db != nil {
+		dbStats := db.Stats()
+		attrs = append(attrs,
+			"db_open_connections", dbStats.OpenConnections,
+			"db_in_use", dbStats.InUse,
+			"db_idle", dbStats.Idle,
+			"db_wait_count", dbStats.WaitCount,
+			"db_wait_duration_ms", dbStats.WaitDuration.Milliseconds(),
+		)
+	}
+
+	logger.Info("runtime stats", attrs...)
+}
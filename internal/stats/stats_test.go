@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockPool struct{}
+
+func (m *mockPool) Stats() sql.DBStats {
+	return sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func TestRun(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, 10*time.Millisecond, logger, &mockPool{})
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(buf.Bytes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	line, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	if len(line) == 0 {
+		t.Fatal("expected at least one stats record")
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(line, &record); err != nil {
+		t.Fatalf("failed to decode stats record: %v", err)
+	}
+
+	if record["msg"] != "runtime stats" {
+		t.Errorf("expected msg 'runtime stats', got %v", record["msg"])
+	}
+	for _, key := range []string{"goroutines", "heap_alloc_bytes", "db_open_connections", "db_in_use", "db_idle"} {
+		if _, ok := record[key]; !ok {
+			t.Errorf("expected %s in stats record", key)
+		}
+	}
+	if record["db_open_connections"] != float64(3) {
+		t.Errorf("expected db_open_connections 3, got %v", record["db_open_connections"])
+	}
+}
@@ -0,0 +1,47 @@
+// Package testutil holds helpers shared by tests across packages.
+package testutil
+
+import (
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// CloseAll registers closers to be closed, in reverse order, when the test
+// finishes and fails the test if any Close returns an error.
+func CloseAll(t testing.TB, closers ...io.Closer) {
+	t.Helper()
+
+	for _, c := range closers {
+		t.Cleanup(func() {
+			if err := c.Close(); err != nil {
+				t.Errorf("failed to close %T: %v", c, err)
+			}
+		})
+	}
+}
+
+// CheckGoroutines records the current goroutine count and, when the test
+// finishes, fails it if more goroutines are still running. Call it before
+// CloseAll so the check runs after every closer.
+func CheckGoroutines(t testing.TB) {
+	t.Helper()
+
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		// Give goroutines stopped by Close a moment to exit
+		deadline := time.Now().Add(2 * time.Second)
+		after := runtime.NumGoroutine()
+		for after > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			after = runtime.NumGoroutine()
+		}
+
+		if after > before {
+			buf := make([]byte, 1<<16)
+			n := runtime.Stack(buf, true)
+			t.Errorf("goroutine leak: %d before, %d after\n%s", before, after, buf[:n])
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package testutil
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeTB captures cleanups and failures so CloseAll can be tested without
+// failing the real test.
+type fakeTB struct {
+	testing.TB
+	cleanups []func()
+	failed   bool
+}
+
+func (f *fakeTB) Helper()                           {}
+func (f *fakeTB) Cleanup(fn func())                 { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Errorf(format string, args ...any) { f.failed = true }
+
+func (f *fakeTB) runCleanups() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+type recordingCloser struct {
+	name  string
+	order *[]string
+	err   error
+}
+
+func (c *recordingCloser) Close() error {
+	*c.order = append(*c.order, c.name)
+	return c.err
+}
+
+func TestCloseAll(t *testing.T) {
+	tests := []struct {
+		name       string
+		closeErr   error
+		wantFailed bool
+	}{
+		{name: "closes cleanly", closeErr: nil, wantFailed: false},
+		{name: "close error fails test", closeErr: errors.New("boom"), wantFailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+			tb := &fakeTB{}
+
+			CloseAll(tb,
+				&recordingCloser{name: "db", order: &order},
+				&recordingCloser{name: "kafka", order: &order, err: tt.closeErr},
+			)
+			if len(order) != 0 {
+				t.Fatalf("closers should not run before cleanup, got %v", order)
+			}
+
+			tb.runCleanups()
+
+			if len(order) != 2 || order[0] != "kafka" || order[1] != "db" {
+				t.Errorf("expected closers to run in reverse order [kafka db], got %v", order)
+			}
+			if tb.failed != tt.wantFailed {
+				t.Errorf("failed = %v, want %v", tb.failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestCheckGoroutines(t *testing.T) {
+	t.Run("stopped goroutine", func(t *testing.T) {
+		tb := &fakeTB{}
+		CheckGoroutines(tb)
+
+		done := make(chan struct{})
+		go func() { close(done) }()
+		<-done
+
+		tb.runCleanups()
+		if tb.failed {
+			t.Error("expected no leak to be reported")
+		}
+	})
+
+	t.Run("leaked goroutine", func(t *testing.T) {
+		tb := &fakeTB{}
+		CheckGoroutines(tb)
+
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() { <-stop }()
+
+		tb.runCleanups()
+		if !tb.failed {
+			t.Error("expected leak to be reported")
+		}
+	})
+}